@@ -1,5 +1,14 @@
 package orange
 
+import (
+	"bytes"
+	"database/sql"
+	"errors"
+	"fmt"
+	"reflect"
+	"unicode"
+)
+
 // Adopter is an interface for database centric sql.
 type Adopter interface {
 
@@ -29,4 +38,110 @@ type Adopter interface {
 
 	//Database returns the current Database
 	Database(*SQL) string
+
+	//CreateDatabase returns sql for creating the database.
+	CreateDatabase(name string) (string, error)
+
+	//DropDatabase returns sql for dropping the database if it exists.
+	DropDatabase(name string) (string, error)
+
+	//Explain returns sql for obtaining the query plan of query. The result
+	//is expected to be rows of a single text column.
+	Explain(query string) string
+
+	//CreateIndex returns sql for creating the index if it does not exist.
+	CreateIndex(Index) (string, error)
+
+	//DropIndex returns sql for dropping the index if it exists.
+	DropIndex(Index) (string, error)
+
+	//ColumnType returns the database type of the column for the field.
+	ColumnType(Field) (string, error)
+
+	//NormalizeType returns the canonical name of a database type. This is used
+	//to compare the types of the models with the types of a live database.
+	NormalizeType(string) string
+
+	//AddColumn returns sql for adding the column of the field to the table.
+	AddColumn(Table, Field) (string, error)
+
+	//AddForeignKey returns sql for adding the foreign key to the table.
+	AddForeignKey(table string, fk ForeignKey) (string, error)
+
+	//DropColumn returns sql for dropping the column of the table.
+	DropColumn(table, column string) (string, error)
+
+	//AlterColumnType returns sql for changing the type of the column of the
+	//field to the type of the field.
+	AlterColumnType(Table, Field) (string, error)
+
+	//RenameTable returns sql for renaming a table.
+	RenameTable(from, to string) (string, error)
+
+	//RenameColumn returns sql for renaming a column of the table.
+	RenameColumn(table, from, to string) (string, error)
+
+	//Truncate returns sql for removing all rows of the table, with
+	//restartIdentity the sequences of the table are reset.
+	Truncate(table string, restartIdentity bool) (string, error)
+
+	//DropCascade returns sql for dropping the table and the objects which
+	//depend on it.
+	DropCascade(table string) (string, error)
+
+	//Inspect returns the schema of the current database.
+	Inspect(*SQL) (*Schema, error)
+
+	//RegisterType sets the column type for fields of the Go type.
+	RegisterType(reflect.Type, string)
+
+	//ConvertValue returns the value written for v when the database stores
+	//the type of v in its own format, for instance slices stored in arrays.
+	//It returns false for values which are written as they are.
+	ConvertValue(v interface{}) (interface{}, bool)
+
+	//Scanner returns a sql.Scanner which scans columns into dest, a pointer to
+	//a field value, when the database stores the type of the field in its own
+	//format. It returns nil for values which are scanned as they are.
+	Scanner(dest interface{}) sql.Scanner
+
+	//GoType returns the Go type for a column of a live database, and the tag
+	//options needed for the column type of the field to match the column. It
+	//is used for generating models.
+	GoType(*ColumnSchema) (string, []string)
+}
+
+// databaseName returns an error if name can not be used as a database name
+// without quoting.
+func databaseName(name string) error {
+	if name == "" {
+		return errors.New("missing database name")
+	}
+	for _, r := range name {
+		if r != '_' && !unicode.IsLetter(r) && !unicode.IsDigit(r) {
+			return fmt.Errorf("invalid database name %s", name)
+		}
+	}
+	return nil
+}
+
+// columnConstraints returns the column constraints declared in the tags of f.
+// The constraints are standard sql which is shared by all adopters.
+//
+//	`sql:"not null;unique;default:0;check:age >= 0"`
+func columnConstraints(f Field) string {
+	buf := &bytes.Buffer{}
+	if hasFlag(f, specialTags.notNull) {
+		_, _ = buf.WriteString(" NOT NULL")
+	}
+	if hasFlag(f, specialTags.unique) {
+		_, _ = buf.WriteString(" UNIQUE")
+	}
+	if v, ok := flagValue(f, specialTags.defaultValue); ok && v != "" {
+		_, _ = buf.WriteString(" DEFAULT " + v)
+	}
+	if v, ok := flagValue(f, specialTags.check); ok && v != "" {
+		_, _ = buf.WriteString(" CHECK (" + v + ")")
+	}
+	return buf.String()
 }
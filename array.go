@@ -0,0 +1,182 @@
+package orange
+
+import (
+	"bytes"
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+)
+
+// isArray returns true if values of typ are stored in array columns. These are
+// slices of strings, numbers and booleans, []byte is not an array.
+func isArray(typ reflect.Type) bool {
+	if typ.Kind() != reflect.Slice {
+		return false
+	}
+	switch typ.Elem().Kind() {
+	case reflect.String, reflect.Bool,
+		reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint16, reflect.Uint32, reflect.Uint64,
+		reflect.Float32, reflect.Float64:
+		return true
+	}
+	return false
+}
+
+// arrayLiteral returns the array literal of the slice v e.g {1,2,3} or
+// {"a","b"}. A nil slice is nil.
+func arrayLiteral(v reflect.Value) interface{} {
+	if v.IsNil() {
+		return nil
+	}
+	buf := &bytes.Buffer{}
+	_ = buf.WriteByte('{')
+	for i := 0; i < v.Len(); i++ {
+		if i > 0 {
+			_ = buf.WriteByte(',')
+		}
+		e := v.Index(i)
+		switch e.Kind() {
+		case reflect.String:
+			r := strings.NewReplacer(`\`, `\\`, `"`, `\"`)
+			_, _ = buf.WriteString(`"` + r.Replace(e.String()) + `"`)
+		default:
+			_, _ = fmt.Fprint(buf, e.Interface())
+		}
+	}
+	_ = buf.WriteByte('}')
+	return buf.String()
+}
+
+// parseArray returns the elements of a one dimensional array literal, NULL
+// elements are nil.
+func parseArray(s string) ([]*string, error) {
+	if len(s) < 2 || s[0] != '{' || s[len(s)-1] != '}' {
+		return nil, fmt.Errorf("invalid array %s", s)
+	}
+	s = s[1 : len(s)-1]
+	var rst []*string
+	for len(s) > 0 {
+		var elem string
+		quoted := s[0] == '"'
+		if quoted {
+			buf := &bytes.Buffer{}
+			i := 1
+			for ; i < len(s) && s[i] != '"'; i++ {
+				if s[i] == '\\' && i+1 < len(s) {
+					i++
+				}
+				_ = buf.WriteByte(s[i])
+			}
+			if i >= len(s) {
+				return nil, fmt.Errorf("unterminated array element %s", s)
+			}
+			elem, s = buf.String(), s[i+1:]
+		} else {
+			n := strings.IndexByte(s, ',')
+			if n < 0 {
+				n = len(s)
+			}
+			elem, s = s[:n], s[n:]
+		}
+		if !quoted && elem == "NULL" {
+			rst = append(rst, nil)
+		} else {
+			v := elem
+			rst = append(rst, &v)
+		}
+		if len(s) > 0 {
+			if s[0] != ',' {
+				return nil, fmt.Errorf("invalid array element %s", s)
+			}
+			s = s[1:]
+		}
+	}
+	return rst, nil
+}
+
+// arrayScanner scans an array literal into the slice that dest points to.
+// NULL elements are zero values.
+type arrayScanner struct {
+	dest reflect.Value
+}
+
+func (a *arrayScanner) Scan(src interface{}) error {
+	var text string
+	switch v := src.(type) {
+	case nil:
+		a.dest.Elem().Set(reflect.Zero(a.dest.Elem().Type()))
+		return nil
+	case []byte:
+		text = string(v)
+	case string:
+		text = v
+	default:
+		return fmt.Errorf("can not scan %T as array", src)
+	}
+	elems, err := parseArray(text)
+	if err != nil {
+		return err
+	}
+	typ := a.dest.Elem().Type()
+	slice := reflect.MakeSlice(typ, len(elems), len(elems))
+	for k, v := range elems {
+		if v == nil {
+			continue
+		}
+		e := slice.Index(k)
+		switch e.Kind() {
+		case reflect.String:
+			e.SetString(*v)
+		case reflect.Bool:
+			e.SetBool(*v == "t" || *v == "true")
+		case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+			n, err := strconv.ParseInt(*v, 10, 64)
+			if err != nil {
+				return err
+			}
+			e.SetInt(n)
+		case reflect.Uint16, reflect.Uint32, reflect.Uint64:
+			n, err := strconv.ParseUint(*v, 10, 64)
+			if err != nil {
+				return err
+			}
+			e.SetUint(n)
+		case reflect.Float32, reflect.Float64:
+			n, err := strconv.ParseFloat(*v, 64)
+			if err != nil {
+				return err
+			}
+			e.SetFloat(n)
+		}
+	}
+	a.dest.Elem().Set(slice)
+	return nil
+}
+
+// WhereAny adds a condition matching rows whose column equals any of values,
+// values is a slice. It uses the postgres ANY operator.
+//
+//	db.WhereAny("id", []int64{1, 2, 3})
+//	// WHERE id = ANY('{1,2,3}')
+func (s *SQL) WhereAny(column string, values interface{}) *SQL {
+	v, err := s.quote(values)
+	if err != nil {
+		return s.queryError(fmt.Errorf("%s: %v", column, err))
+	}
+	return s.andWhere(fmt.Sprintf(" %s = ANY(%s)", column, v))
+}
+
+// WhereArrayContains adds a condition matching rows whose array column
+// contains all values, values is a slice. It uses the postgres @> operator.
+//
+//	db.WhereArrayContains("tags", []string{"go"})
+//	// WHERE tags @> '{"go"}'
+func (s *SQL) WhereArrayContains(column string, values interface{}) *SQL {
+	v, err := s.quote(values)
+	if err != nil {
+		return s.queryError(fmt.Errorf("%s: %v", column, err))
+	}
+	return s.andWhere(fmt.Sprintf(" %s @> %s", column, v))
+}
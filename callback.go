@@ -0,0 +1,188 @@
+package orange
+
+import "sync"
+
+// Scope is the state of an operation that is passed to callbacks.
+type Scope struct {
+	// SQL is the *SQL performing the operation. Queries made through it run in
+	// the same transaction as the operation.
+	SQL *SQL
+
+	Operation Operation
+	Model     interface{}
+	Table     Table
+
+	// Query is the statement of the operation. It is built once before the
+	// Before callbacks are called and executed as they leave it. Before
+	// callbacks may replace it, callbacks which change the model call Rebuild
+	// for the change to be written.
+	Query string
+
+	// Args are the arguments of Query.
+	Args []interface{}
+
+	build func() (string, []interface{}, error)
+}
+
+// Rebuild builds Query and Args again from the model and the conditions of the
+// operation, changes made to them are discarded.
+func (s *Scope) Rebuild() error {
+	if s.build == nil {
+		return nil
+	}
+	query, args, err := s.build()
+	if err != nil {
+		return err
+	}
+	s.Query, s.Args = query, args
+	return nil
+}
+
+// CallbackFunc is a function that processes an operation. Returning an error
+// aborts the operation.
+type CallbackFunc func(*Scope) error
+
+type namedCallback struct {
+	name string
+	fn   CallbackFunc
+}
+
+// Processor is an ordered collection of callbacks for a single operation.
+type Processor struct {
+	mu     sync.RWMutex
+	before []namedCallback
+	after  []namedCallback
+}
+
+// Before registers fn to be called before the statement is executed. Callbacks
+// are called in the order they are registered, registering a name which is
+// already in use replaces the existing callback.
+func (p *Processor) Before(name string, fn CallbackFunc) *Processor {
+	p.mu.Lock()
+	p.before = register(p.before, name, fn)
+	p.mu.Unlock()
+	return p
+}
+
+// After registers fn to be called after the statement is executed
+// successfully.
+func (p *Processor) After(name string, fn CallbackFunc) *Processor {
+	p.mu.Lock()
+	p.after = register(p.after, name, fn)
+	p.mu.Unlock()
+	return p
+}
+
+// Remove removes the callbacks registered with name.
+func (p *Processor) Remove(name string) *Processor {
+	p.mu.Lock()
+	p.before = remove(p.before, name)
+	p.after = remove(p.after, name)
+	p.mu.Unlock()
+	return p
+}
+
+func register(cbs []namedCallback, name string, fn CallbackFunc) []namedCallback {
+	for k, v := range cbs {
+		if v.name == name {
+			cbs[k].fn = fn
+			return cbs
+		}
+	}
+	return append(cbs, namedCallback{name: name, fn: fn})
+}
+
+func remove(cbs []namedCallback, name string) []namedCallback {
+	var rst []namedCallback
+	for _, v := range cbs {
+		if v.name != name {
+			rst = append(rst, v)
+		}
+	}
+	return rst
+}
+
+func (p *Processor) runBefore(scope *Scope) error {
+	p.mu.RLock()
+	cbs := p.before
+	p.mu.RUnlock()
+	return run(cbs, scope)
+}
+
+func (p *Processor) runAfter(scope *Scope) error {
+	p.mu.RLock()
+	cbs := p.after
+	p.mu.RUnlock()
+	return run(cbs, scope)
+}
+
+func run(cbs []namedCallback, scope *Scope) error {
+	for _, v := range cbs {
+		if err := v.fn(scope); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Callbacks is a registry of callbacks for the operations performed on models.
+// This is where cross cutting concerns like auditing can be plugged in.
+//
+//	db.Callbacks().Create().Before("audit", func(s *orange.Scope) error {
+//		// ...
+//		return nil
+//	})
+type Callbacks struct {
+	create, update, delete, query *Processor
+}
+
+func newCallbacks() *Callbacks {
+	return &Callbacks{
+		create: &Processor{},
+		update: &Processor{},
+		delete: &Processor{},
+		query:  &Processor{},
+	}
+}
+
+// Create returns callbacks for inserting models.
+func (c *Callbacks) Create() *Processor {
+	return c.create
+}
+
+// Update returns callbacks for updating models.
+func (c *Callbacks) Update() *Processor {
+	return c.update
+}
+
+// Delete returns callbacks for deleting models.
+func (c *Callbacks) Delete() *Processor {
+	return c.delete
+}
+
+// Query returns callbacks for loading models. The After callbacks are called
+// once the results have been assigned to the model.
+func (c *Callbacks) Query() *Processor {
+	return c.query
+}
+
+// processor returns the callbacks for op.
+func (c *Callbacks) processor(op Operation) *Processor {
+	switch op {
+	case OpCreate:
+		return c.create
+	case OpUpdate:
+		return c.update
+	case OpDelete:
+		return c.delete
+	case OpSelect:
+		return c.query
+	}
+	return &Processor{}
+}
+
+// Callbacks returns the callback registry of s. The registry is shared by all
+// copies of s.
+func (s *SQL) Callbacks() *Callbacks {
+	return s.callbacks
+}
@@ -0,0 +1,98 @@
+package orange
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestSQL_Callbacks(t *testing.T) {
+	db, err := Open("postgres", testDB.ps)
+	if err != nil {
+		t.Fatal(err)
+	}
+	_ = db.Register(&golangster{})
+	var order []string
+	errStop := errors.New("stop")
+	db.Callbacks().Create().
+		Before("tenant", func(s *Scope) error {
+			order = append(order, "tenant")
+			s.Model.(*golangster).Name = "tenant"
+			return nil
+		}).
+		Before("audit", func(s *Scope) error {
+			order = append(order, "audit")
+			if s.Table.Name() != "golangster" {
+				t.Errorf("expected golangster got %s", s.Table.Name())
+			}
+			return errStop
+		})
+	m := &golangster{}
+	err = db.DryRun().Create(m)
+	if err != errStop {
+		t.Errorf("expected %v got %v", errStop, err)
+	}
+	if len(order) != 2 || order[0] != "tenant" || order[1] != "audit" {
+		t.Errorf("expected callbacks in registration order got %v", order)
+	}
+	if m.Name != "tenant" {
+		t.Errorf("expected tenant got %s", m.Name)
+	}
+
+	// copies share the registry
+	order = nil
+	db.Copy().Callbacks().Create().Remove("audit")
+	err = db.Callbacks().Create().runBefore(&Scope{Model: m, Table: nil})
+	if err != nil {
+		t.Error(err)
+	}
+	if len(order) != 1 {
+		t.Errorf("expected 1 callback got %d", len(order))
+	}
+}
+
+func TestSQL_CallbackQuery(t *testing.T) {
+	db, err := Open("postgres", testDB.ps)
+	if err != nil {
+		t.Fatal(err)
+	}
+	_ = db.Register(&golangster{})
+	db.Callbacks().Update().Before("rename", func(s *Scope) error {
+		if s.Query == "" {
+			t.Error("expected the query to be built")
+		}
+		s.Model.(*golangster).Name = "renamed"
+		return s.Rebuild()
+	})
+	db.Callbacks().Update().Before("stale", func(s *Scope) error {
+		// changes to the model after the last Rebuild are not written.
+		s.Model.(*golangster).Name = "stale"
+		return nil
+	})
+	db.Callbacks().Delete().Before("soft", func(s *Scope) error {
+		s.Query = strings.Replace(s.Query, "DELETE FROM golangster", "UPDATE golangster SET name='deleted'", 1)
+		return nil
+	})
+	dry := db.DryRun()
+	err = dry.Update(&golangster{ID: 1, Name: "gernest"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = dry.Delete(&golangster{ID: 1})
+	if err != nil {
+		t.Fatal(err)
+	}
+	expect := []string{
+		"UPDATE golangster SET name ='renamed' WHERE id=$1",
+		"UPDATE golangster SET name='deleted' WHERE id=$1",
+	}
+	got := dry.Statements()
+	if len(got) != len(expect) {
+		t.Fatalf("expected %d statements got %d", len(expect), len(got))
+	}
+	for k, v := range expect {
+		if got[k].Query != v {
+			t.Errorf("expected %s got %s", v, got[k].Query)
+		}
+	}
+}
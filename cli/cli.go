@@ -0,0 +1,125 @@
+// Package cli implements the orange command.
+//
+// The orange command only knows about the database, to work with models and Go
+// migrations build a command which registers them with the setup function.
+//
+//	func main() {
+//		cli.Main(os.Args[1:], func(db *orange.SQL) error {
+//			err := db.Register(&User{}, &Post{})
+//			if err != nil {
+//				return err
+//			}
+//			return db.Migrations().Add(migrations...)
+//		})
+//	}
+//
+// The connection is read from the -adopter and -dsn flags, which default to the
+// ORANGE_ADOPTER and ORANGE_DSN environment variables.
+package cli
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/gernest/orange"
+)
+
+const usage = `usage: orange <command> [flags]
+
+commands:
+	gen			generate Go models from the tables of the database
+	migrate up|down|status	apply, roll back or list migrations
+	create <name>		create a new sql migration
+	schema dump		print the DDL of the registered models
+	diff			print the differences between the models and the database
+	db create|drop <name>	create or drop a database
+
+Run orange <command> -h for the flags of a command.
+`
+
+// SetupFunc prepares the database before a command runs, it is used for
+// registering models and migrations.
+type SetupFunc func(*orange.SQL) error
+
+// Main runs the orange command with args, the process exits with status 1 when
+// the command fails.
+func Main(args []string, setup SetupFunc) {
+	err := Run(args, os.Stdout, setup)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "orange:", err)
+		os.Exit(1)
+	}
+}
+
+// Run runs the orange command with args and writes the output to stdout.
+func Run(args []string, stdout io.Writer, setup SetupFunc) error {
+	c := &command{stdout: stdout, setup: setup}
+	if len(args) == 0 {
+		fmt.Fprint(os.Stderr, usage)
+		return fmt.Errorf("missing command")
+	}
+	switch args[0] {
+	case "gen":
+		return c.gen(args[1:])
+	case "migrate":
+		return c.migrate(args[1:])
+	case "create":
+		return c.create(args[1:])
+	case "schema":
+		return c.schema(args[1:])
+	case "diff":
+		return c.diff(args[1:])
+	case "db":
+		return c.database(args[1:])
+	case "help", "-h", "-help", "--help":
+		fmt.Fprint(stdout, usage)
+		return nil
+	}
+	fmt.Fprint(os.Stderr, usage)
+	return fmt.Errorf("unknown command %s", args[0])
+}
+
+// command holds the state shared by the commands.
+type command struct {
+	stdout  io.Writer
+	setup   SetupFunc
+	adopter string
+	dsn     string
+}
+
+// flags returns the flag set of the command name with the connection flags.
+func (c *command) flags(name string) *flag.FlagSet {
+	fs := flag.NewFlagSet(name, flag.ContinueOnError)
+	adopter := os.Getenv("ORANGE_ADOPTER")
+	if adopter == "" {
+		adopter = "postgres"
+	}
+	fs.StringVar(&c.adopter, "adopter", adopter, "name of the adopter, env ORANGE_ADOPTER")
+	fs.StringVar(&c.dsn, "dsn", os.Getenv("ORANGE_DSN"), "data source name of the database, env ORANGE_DSN")
+	return fs
+}
+
+// open connects to the database and runs the setup function.
+func (c *command) open() (*orange.SQL, error) {
+	if c.dsn == "" {
+		return nil, fmt.Errorf("missing connection, use -dsn or ORANGE_DSN")
+	}
+	return c.openDSN(c.dsn)
+}
+
+func (c *command) openDSN(dsn string) (*orange.SQL, error) {
+	db, err := orange.Open(c.adopter, dsn)
+	if err != nil {
+		return nil, err
+	}
+	if c.setup != nil {
+		err = c.setup(db)
+		if err != nil {
+			_ = db.DB().Close()
+			return nil, err
+		}
+	}
+	return db, nil
+}
@@ -0,0 +1,82 @@
+package cli
+
+import (
+	"bytes"
+	"errors"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/gernest/orange"
+
+	_ "github.com/lib/pq"
+)
+
+type account struct {
+	ID    int64
+	Email string `sql:"unique_index"`
+}
+
+func TestCreate(t *testing.T) {
+	dir := t.TempDir()
+	out := &bytes.Buffer{}
+	err := Run([]string{"create", "-dir", dir, "add_accounts"}, out, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	files, err := filepath.Glob(filepath.Join(dir, "*_add_accounts.*.sql"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(files) != 2 {
+		t.Fatalf("expected 2 files got %v", files)
+	}
+	for _, v := range files {
+		if !strings.Contains(out.String(), v+"\n") {
+			t.Errorf("expected %s to be printed got %s", v, out)
+		}
+	}
+	err = Run([]string{"create", "-dir", dir, "Add Accounts"}, out, nil)
+	if err == nil {
+		t.Error("expected an error for an invalid name")
+	}
+}
+
+func TestSchemaDump(t *testing.T) {
+	t.Setenv("ORANGE_DSN", "")
+	out := &bytes.Buffer{}
+	err := Run([]string{"schema", "dump"}, out, func(db *orange.SQL) error {
+		return db.Register(&account{})
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	expect := "CREATE TABLE IF NOT EXISTS account (id bigserial,email text,PRIMARY KEY (id));\n" +
+		"CREATE UNIQUE INDEX IF NOT EXISTS idx_account_email ON account (email);\n"
+	if out.String() != expect {
+		t.Errorf("expected %s got %s", expect, out)
+	}
+	err = Run([]string{"schema", "dump"}, out, nil)
+	if !errors.Is(err, orange.ErrNoModels) {
+		t.Errorf("expected %v got %v", orange.ErrNoModels, err)
+	}
+}
+
+func TestDiffWithoutModels(t *testing.T) {
+	out := &bytes.Buffer{}
+	err := Run([]string{"diff", "-dsn", "dbname=orange_test sslmode=disable"}, out, nil)
+	if !errors.Is(err, orange.ErrNoModels) {
+		t.Errorf("expected %v got %v", orange.ErrNoModels, err)
+	}
+}
+
+func TestMigrateMissingDir(t *testing.T) {
+	out := &bytes.Buffer{}
+	dir := filepath.Join(t.TempDir(), "migrationz")
+	for _, v := range []string{"up", "down", "status"} {
+		err := Run([]string{"migrate", v, "-dir", dir, "-dsn", "dbname=orange_test sslmode=disable"}, out, nil)
+		if err == nil || !strings.Contains(err.Error(), dir) {
+			t.Errorf("%s: expected an error for the missing directory got %v", v, err)
+		}
+	}
+}
@@ -0,0 +1,30 @@
+package cli
+
+import (
+	"fmt"
+)
+
+// database creates or drops a database. The connection has to be to another
+// database of the same server.
+func (c *command) database(args []string) error {
+	if len(args) == 0 || (args[0] != "create" && args[0] != "drop") {
+		return fmt.Errorf("usage: orange db create|drop [flags] <name>")
+	}
+	sub := args[0]
+	fs := c.flags("db " + sub)
+	if err := fs.Parse(args[1:]); err != nil {
+		return err
+	}
+	if fs.NArg() != 1 {
+		return fmt.Errorf("usage: orange db %s [flags] <name>", sub)
+	}
+	db, err := c.open()
+	if err != nil {
+		return err
+	}
+	defer func() { _ = db.DB().Close() }()
+	if sub == "create" {
+		return db.CreateDatabase(fs.Arg(0))
+	}
+	return db.DropDatabase(fs.Arg(0))
+}
@@ -0,0 +1,35 @@
+package cli
+
+import (
+	"os"
+	"strings"
+)
+
+// gen writes Go models for the tables of the database.
+func (c *command) gen(args []string) error {
+	fs := c.flags("gen")
+	pkg := fs.String("pkg", "models", "package name of the generated code")
+	out := fs.String("out", "", "file to write the models to, stdout is used when empty")
+	tables := fs.String("tables", "", "comma separated tables to generate, all tables when empty")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	db, err := c.open()
+	if err != nil {
+		return err
+	}
+	defer func() { _ = db.DB().Close() }()
+	var names []string
+	if *tables != "" {
+		names = strings.Split(*tables, ",")
+	}
+	src, err := db.GenerateModels(*pkg, names...)
+	if err != nil {
+		return err
+	}
+	if *out == "" {
+		_, err = c.stdout.Write(src)
+		return err
+	}
+	return os.WriteFile(*out, src, 0644)
+}
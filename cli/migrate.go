@@ -0,0 +1,133 @@
+package cli
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"regexp"
+	"text/tabwriter"
+	"time"
+
+	"github.com/gernest/orange"
+)
+
+// migrate applies, rolls back or lists the migrations.
+func (c *command) migrate(args []string) error {
+	if len(args) == 0 {
+		return fmt.Errorf("usage: orange migrate up|down|status [flags]")
+	}
+	sub := args[0]
+	fs := c.flags("migrate " + sub)
+	dir := fs.String("dir", "migrations", "directory of the sql migrations")
+	var to int64
+	var steps int
+	switch sub {
+	case "up":
+		fs.Int64Var(&to, "to", 0, "version to migrate to, the latest version when 0")
+	case "down":
+		fs.IntVar(&steps, "steps", 1, "number of migrations to roll back")
+	case "status":
+	default:
+		return fmt.Errorf("unknown migrate command %s", sub)
+	}
+	if err := fs.Parse(args[1:]); err != nil {
+		return err
+	}
+	// only the default directory is optional, a directory given with -dir
+	// must exist.
+	load := true
+	if _, err := os.Stat(*dir); err != nil {
+		if isSet(fs, "dir") {
+			return fmt.Errorf("migrations directory %s: %v", *dir, err)
+		}
+		load = false
+	}
+	db, err := c.open()
+	if err != nil {
+		return err
+	}
+	defer func() { _ = db.DB().Close() }()
+	m := db.Migrations()
+	if load {
+		err = m.Load(os.DirFS(*dir), ".")
+		if err != nil {
+			return err
+		}
+	}
+	switch sub {
+	case "up":
+		if to > 0 {
+			return m.MigrateTo(to)
+		}
+		return m.Migrate()
+	case "down":
+		return m.Rollback(steps)
+	}
+	status, err := m.Status()
+	if err != nil {
+		return err
+	}
+	return printStatus(c.stdout, status)
+}
+
+// isSet returns true if the flag named name was set on the command line.
+func isSet(fs *flag.FlagSet, name string) bool {
+	set := false
+	fs.Visit(func(f *flag.Flag) {
+		if f.Name == name {
+			set = true
+		}
+	})
+	return set
+}
+
+func printStatus(w io.Writer, status []orange.MigrationStatus) error {
+	tw := tabwriter.NewWriter(w, 0, 8, 2, ' ', 0)
+	fmt.Fprintln(tw, "VERSION\tNAME\tSTATUS")
+	for _, v := range status {
+		state := "pending"
+		switch {
+		case v.Missing:
+			state = "applied " + v.AppliedAt.Format(time.RFC3339) + ", missing"
+		case v.Applied:
+			state = "applied " + v.AppliedAt.Format(time.RFC3339)
+		}
+		fmt.Fprintf(tw, "%d\t%s\t%s\n", v.Version, v.Name, state)
+	}
+	return tw.Flush()
+}
+
+var migrationName = regexp.MustCompile(`^[a-z0-9_]+$`)
+
+// create writes the up and down files of a new sql migration, the version is
+// the current time.
+func (c *command) create(args []string) error {
+	fs := c.flags("create")
+	dir := fs.String("dir", "migrations", "directory of the sql migrations")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if fs.NArg() != 1 || !migrationName.MatchString(fs.Arg(0)) {
+		return fmt.Errorf("usage: orange create [flags] <name>, the name is made of lower case letters, digits and _")
+	}
+	err := os.MkdirAll(*dir, 0755)
+	if err != nil {
+		return err
+	}
+	base := time.Now().UTC().Format("20060102150405") + "_" + fs.Arg(0)
+	for _, v := range []string{".up.sql", ".down.sql"} {
+		name := filepath.Join(*dir, base+v)
+		f, err := os.OpenFile(name, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0644)
+		if err != nil {
+			return err
+		}
+		err = f.Close()
+		if err != nil {
+			return err
+		}
+		fmt.Fprintln(c.stdout, name)
+	}
+	return nil
+}
@@ -0,0 +1,67 @@
+package cli
+
+import (
+	"errors"
+	"fmt"
+	"io"
+
+	"github.com/gernest/orange"
+)
+
+// schema prints the DDL of the registered models.
+func (c *command) schema(args []string) error {
+	if len(args) == 0 || args[0] != "dump" {
+		return fmt.Errorf("usage: orange schema dump [flags]")
+	}
+	fs := c.flags("schema dump")
+	if err := fs.Parse(args[1:]); err != nil {
+		return err
+	}
+
+	// the database is not used, the connection is only needed for the adopter.
+	db, err := c.openDSN(c.dsn)
+	if err != nil {
+		return err
+	}
+	defer func() { _ = db.DB().Close() }()
+	script, err := db.SchemaSQL()
+	if err != nil {
+		return modelsError(err)
+	}
+	_, err = io.WriteString(c.stdout, script)
+	return err
+}
+
+// diff prints the differences between the registered models and the
+// database, it fails when there are differences.
+func (c *command) diff(args []string) error {
+	fs := c.flags("diff")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	db, err := c.open()
+	if err != nil {
+		return err
+	}
+	defer func() { _ = db.DB().Close() }()
+	diffs, err := db.Verify()
+	if err != nil {
+		return modelsError(err)
+	}
+	for _, v := range diffs {
+		fmt.Fprintln(c.stdout, v)
+	}
+	if len(diffs) > 0 {
+		return fmt.Errorf("the database differs from the models in %d places", len(diffs))
+	}
+	return nil
+}
+
+// modelsError explains how to register models when err is
+// orange.ErrNoModels, the orange command itself does not know any models.
+func modelsError(err error) error {
+	if errors.Is(err, orange.ErrNoModels) {
+		return fmt.Errorf("%w, build a command which registers them with the setup function of cli.Main", err)
+	}
+	return err
+}
@@ -0,0 +1,25 @@
+// Command orange works with databases using the orange adopters.
+//
+//	orange gen -dsn "user=postgres dbname=app sslmode=disable" -pkg models -out models/models.go
+//	orange migrate up -dir migrations
+//
+// The command does not know about any models, so schema dump and diff fail
+// with "no models registered". See the cli package for building a command that
+// registers your models.
+//
+// migrate loads the sql migrations of -dir, the default migrations directory is
+// skipped when it does not exist but a directory given with -dir must exist.
+package main
+
+import (
+	"os"
+
+	"github.com/gernest/orange/cli"
+
+	// drivers for the supported adopters.
+	_ "github.com/lib/pq"
+)
+
+func main() {
+	cli.Main(os.Args[1:], nil)
+}
@@ -0,0 +1,172 @@
+package orange
+
+import (
+	"bytes"
+	"context"
+	"reflect"
+	"strings"
+)
+
+// commentTag is a key=value pair written in statement comments.
+type commentTag struct {
+	key, value string
+}
+
+// comments holds the settings for tagging statements with comments.
+type comments struct {
+	tags     []commentTag
+	prepend  bool
+	disabled bool
+}
+
+// copy returns a copy of c which does not share its tags with c.
+func (c *comments) copy() *comments {
+	if c == nil {
+		return nil
+	}
+	dup := *c
+	dup.tags = make([]commentTag, len(c.tags))
+	copy(dup.tags, c.tags)
+	return &dup
+}
+
+type commentKey struct{}
+
+// WithComment returns a copy of ctx which carries the tag key=value. The tag
+// is added to the comment of every statement executed with the returned
+// context, see *SQL.WithContext.
+func WithComment(ctx context.Context, key, value string) context.Context {
+	parent, _ := ctx.Value(commentKey{}).([]commentTag)
+	tags := make([]commentTag, len(parent), len(parent)+1)
+	copy(tags, parent)
+	tags = append(tags, commentTag{key: key, value: value})
+	return context.WithValue(ctx, commentKey{}, tags)
+}
+
+// WithContext returns a copy of s whose statements are executed with ctx.
+func (s *SQL) WithContext(ctx context.Context) *SQL {
+	dup := s.Copy()
+	dup.ctx = ctx
+	return dup
+}
+
+// context returns the context in which statements are executed.
+func (s *SQL) context() context.Context {
+	if s.ctx != nil {
+		return s.ctx
+	}
+	return context.Background()
+}
+
+// EnableComments turns on tagging of statements with a comment like
+//
+//	/* app=billing,route=/invoices,model=Invoice */
+//
+// The comment is made of the tags added with Comment, followed by the tags
+// carried by the context and the name of the model the statement is built
+// from. When prepend is true the comment is placed before the statement,
+// otherwise it is appended.
+func (s *SQL) EnableComments(prepend bool) *SQL {
+	if s.comments == nil {
+		s.comments = &comments{}
+	}
+	s.comments.prepend = prepend
+	s.comments.disabled = false
+	return s
+}
+
+// DisableComments turns off tagging of statements with comments, including
+// the tags carried by the context. The tags added with Comment are kept and
+// used again when comments are enabled.
+func (s *SQL) DisableComments() *SQL {
+	if s.comments == nil {
+		s.comments = &comments{}
+	}
+	s.comments.disabled = true
+	return s
+}
+
+// Comment adds key=value to the comment of every statement executed by s. This
+// enables comments if they were not enabled already.
+func (s *SQL) Comment(key, value string) *SQL {
+	if s.comments == nil {
+		s.comments = &comments{}
+	}
+	s.comments.tags = append(s.comments.tags, commentTag{key: key, value: value})
+	s.comments.disabled = false
+	return s
+}
+
+// annotate returns the query of st with the comment tags added.
+func (s *SQL) annotate(st *statement) string {
+	if s.comments != nil && s.comments.disabled {
+		return st.query
+	}
+	ctxTags, _ := s.context().Value(commentKey{}).([]commentTag)
+	if s.comments == nil && ctxTags == nil {
+		return st.query
+	}
+	var tags []commentTag
+	prepend := false
+	if s.comments != nil {
+		tags = append(tags, s.comments.tags...)
+		prepend = s.comments.prepend
+	}
+	tags = append(tags, ctxTags...)
+	if st.model != "" {
+		tags = append(tags, commentTag{key: "model", value: st.model})
+	}
+	if len(tags) == 0 {
+		return st.query
+	}
+	buf := &bytes.Buffer{}
+	_, _ = buf.WriteString("/* ")
+	for k, v := range tags {
+		if k > 0 {
+			_, _ = buf.WriteString(",")
+		}
+		_, _ = buf.WriteString(cleanComment(v.key) + "=" + cleanComment(v.value))
+	}
+	_, _ = buf.WriteString(" */")
+	if prepend {
+		return buf.String() + " " + st.query
+	}
+	query := strings.TrimSpace(st.query)
+	if strings.HasSuffix(query, ";") {
+		return strings.TrimSuffix(query, ";") + " " + buf.String() + ";"
+	}
+	return query + " " + buf.String()
+}
+
+// stripComment removes a leading /* ... */ comment from query.
+func stripComment(query string) string {
+	q := strings.TrimSpace(query)
+	if !strings.HasPrefix(q, "/*") {
+		return q
+	}
+	if n := strings.Index(q, "*/"); n >= 0 {
+		return strings.TrimSpace(q[n+2:])
+	}
+	return q
+}
+
+// cleanComment removes sequences that would terminate or nest the comment.
+func cleanComment(v string) string {
+	v = strings.Replace(v, "*/", "", -1)
+	return strings.Replace(v, "/*", "", -1)
+}
+
+// modelName returns the name of the struct type of model.
+func modelName(model interface{}) string {
+	typ := reflect.TypeOf(model)
+	if typ == nil {
+		return ""
+	}
+	for typ.Kind() == reflect.Ptr || typ.Kind() == reflect.Slice {
+		typ = typ.Elem()
+	}
+	if typ.Kind() != reflect.Struct {
+		return ""
+	}
+	return typ.Name()
+}
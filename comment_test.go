@@ -0,0 +1,84 @@
+package orange
+
+import (
+	"context"
+	"testing"
+)
+
+func TestSQL_Comment(t *testing.T) {
+	db, err := Open("postgres", testDB.ps)
+	if err != nil {
+		t.Fatal(err)
+	}
+	st := &statement{query: "SELECT * FROM golangster;", model: "golangster"}
+	if q := db.annotate(st); q != st.query {
+		t.Errorf("expected %s got %s", st.query, q)
+	}
+
+	db.Comment("app", "billing")
+	ctx := WithComment(context.Background(), "route", "/invoices")
+	scoped := db.WithContext(ctx)
+	expect := "SELECT * FROM golangster /* app=billing,route=/invoices,model=golangster */;"
+	if q := scoped.annotate(st); q != expect {
+		t.Errorf("expected %s got %s", expect, q)
+	}
+
+	// the parent is not affected by the context of the copy
+	expect = "SELECT * FROM golangster /* app=billing,model=golangster */;"
+	if q := db.annotate(st); q != expect {
+		t.Errorf("expected %s got %s", expect, q)
+	}
+
+	// tags added to a copy are not added to the parent
+	db.Copy().Comment("route", "/copy")
+	if q := db.annotate(st); q != expect {
+		t.Errorf("expected %s got %s", expect, q)
+	}
+
+	db.EnableComments(true)
+	expect = "/* app=billing,model=golangster */ SELECT * FROM golangster;"
+	if q := db.annotate(st); q != expect {
+		t.Errorf("expected %s got %s", expect, q)
+	}
+
+	// disabling drops the context tags too
+	scoped = db.WithContext(ctx).DisableComments()
+	if q := scoped.annotate(st); q != st.query {
+		t.Errorf("expected %s got %s", st.query, q)
+	}
+	if q := db.annotate(st); q != expect {
+		t.Errorf("expected %s got %s", expect, q)
+	}
+}
+
+func TestCleanComment(t *testing.T) {
+	sample := []struct {
+		value, expect string
+	}{
+		{"billing", "billing"},
+		{"*/ DROP TABLE golangster; /*", " DROP TABLE golangster; "},
+	}
+	for _, v := range sample {
+		if c := cleanComment(v.value); c != v.expect {
+			t.Errorf("expected %s got %s", v.expect, c)
+		}
+	}
+}
+
+func TestModelName(t *testing.T) {
+	sample := []struct {
+		model  interface{}
+		expect string
+	}{
+		{&golangster{}, "golangster"},
+		{golangster{}, "golangster"},
+		{&[]golangster{}, "golangster"},
+		{new(int), ""},
+		{nil, ""},
+	}
+	for _, v := range sample {
+		if n := modelName(v.model); n != v.expect {
+			t.Errorf("expected %s got %s", v.expect, n)
+		}
+	}
+}
@@ -0,0 +1,132 @@
+package orange
+
+import (
+	"fmt"
+)
+
+// tableName returns the name of table, which is either the name of a table or
+// a model.
+func (s *SQL) tableName(table interface{}) (string, error) {
+	if name, ok := table.(string); ok {
+		return name, nil
+	}
+	t, err := s.loader(table)
+	if err != nil {
+		return "", err
+	}
+	return t.Name(), nil
+}
+
+// modelField returns the table of model and its field whose column is column.
+func (s *SQL) modelField(model interface{}, column string) (Table, Field, error) {
+	t, err := s.loader(model)
+	if err != nil {
+		return nil, nil, err
+	}
+	fields, err := t.Fields()
+	if err != nil {
+		return nil, nil, err
+	}
+	for _, f := range fields {
+		if f.ColumnName() == column {
+			return t, f, nil
+		}
+	}
+	return nil, nil, fmt.Errorf("table %s has no column %s", t.Name(), column)
+}
+
+// ddl executes the statement returned by the adopter for table.
+func (s *SQL) ddl(table string, query string, err error) error {
+	if err != nil {
+		return err
+	}
+	_, err = s.exec(&statement{op: OpMigrate, table: table, query: query})
+	return err
+}
+
+// RenameTable renames the table from to to. The tables are either table names
+// or models.
+//
+//	err := db.RenameTable("users", &account{})
+func (s *SQL) RenameTable(from, to interface{}) error {
+	src, err := s.tableName(from)
+	if err != nil {
+		return err
+	}
+	dst, err := s.tableName(to)
+	if err != nil {
+		return err
+	}
+	query, err := s.adopter.RenameTable(src, dst)
+	return s.ddl(src, query, err)
+}
+
+// RenameColumn renames the column from of table to to, table is either a table
+// name or a model.
+func (s *SQL) RenameColumn(table interface{}, from, to string) error {
+	name, err := s.tableName(table)
+	if err != nil {
+		return err
+	}
+	query, err := s.adopter.RenameColumn(name, from, to)
+	return s.ddl(name, query, err)
+}
+
+// AddColumn adds the column of model to its table. The column is defined by the
+// tags of the field whose column name is column.
+func (s *SQL) AddColumn(model interface{}, column string) error {
+	t, err := s.loader(model)
+	if err != nil {
+		return err
+	}
+	t, err = s.resolve(t)
+	if err != nil {
+		return err
+	}
+	return s.addColumn(t, column)
+}
+
+// DropColumn drops the column of table if it exists, table is either a table
+// name or a model.
+func (s *SQL) DropColumn(table interface{}, column string) error {
+	name, err := s.tableName(table)
+	if err != nil {
+		return err
+	}
+	query, err := s.adopter.DropColumn(name, column)
+	return s.ddl(name, query, err)
+}
+
+// AlterColumnType changes the type of the column of model to the type of the
+// field whose column name is column.
+func (s *SQL) AlterColumnType(model interface{}, column string) error {
+	t, f, err := s.modelField(model, column)
+	if err != nil {
+		return err
+	}
+	query, err := s.adopter.AlterColumnType(t, f)
+	return s.ddl(t.Name(), query, err)
+}
+
+// Truncate removes all rows of table, with restartIdentity the auto
+// incremented columns start again from the beginning. table is either a table
+// name or a model.
+func (s *SQL) Truncate(table interface{}, restartIdentity bool) error {
+	name, err := s.tableName(table)
+	if err != nil {
+		return err
+	}
+	query, err := s.adopter.Truncate(name, restartIdentity)
+	return s.ddl(name, query, err)
+}
+
+// DropTableCascade drops table and the objects which depend on it, like the
+// foreign keys of other tables. table is either a table name or a model.
+func (s *SQL) DropTableCascade(table interface{}) error {
+	name, err := s.tableName(table)
+	if err != nil {
+		return err
+	}
+	query, err := s.adopter.DropCascade(name)
+	return s.ddl(name, query, err)
+}
@@ -0,0 +1,59 @@
+package orange
+
+import (
+	"testing"
+)
+
+type ddlModel struct {
+	ID    int64
+	Name  string `sql:"size:64;not null"`
+	Score int64
+}
+
+func TestSQL_DDL(t *testing.T) {
+	db, err := Open("postgres", testDB.ps)
+	if err != nil {
+		t.Fatal(err)
+	}
+	dry := db.DryRun()
+	sample := []struct {
+		run    func() error
+		expect string
+	}{
+		{func() error { return dry.RenameTable("users", &ddlModel{}) },
+			"ALTER TABLE users RENAME TO ddl_model;"},
+		{func() error { return dry.RenameColumn(&ddlModel{}, "full_name", "name") },
+			"ALTER TABLE ddl_model RENAME COLUMN full_name TO name;"},
+		{func() error { return dry.AddColumn(&ddlModel{}, "name") },
+			"ALTER TABLE ddl_model ADD COLUMN IF NOT EXISTS name varchar(64) NOT NULL;"},
+		{func() error { return dry.DropColumn("ddl_model", "nickname") },
+			"ALTER TABLE ddl_model DROP COLUMN IF EXISTS nickname;"},
+		{func() error { return dry.AlterColumnType(&ddlModel{}, "score") },
+			"ALTER TABLE ddl_model ALTER COLUMN score TYPE bigint USING score::bigint;"},
+		{func() error { return dry.AlterColumnType(&ddlModel{}, "id") },
+			"ALTER TABLE ddl_model ALTER COLUMN id TYPE bigint USING id::bigint;"},
+		{func() error { return dry.Truncate(&ddlModel{}, false) },
+			"TRUNCATE TABLE ddl_model;"},
+		{func() error { return dry.Truncate("ddl_model", true) },
+			"TRUNCATE TABLE ddl_model RESTART IDENTITY;"},
+		{func() error { return dry.DropTableCascade(&ddlModel{}) },
+			"DROP TABLE IF EXISTS ddl_model CASCADE;"},
+	}
+	for k, v := range sample {
+		err = v.run()
+		if err != nil {
+			t.Fatal(err)
+		}
+		got := dry.Statements()
+		if len(got) != k+1 {
+			t.Fatalf("expected %d statements got %d", k+1, len(got))
+		}
+		if got[k].Query != v.expect {
+			t.Errorf("expected %s got %s", v.expect, got[k].Query)
+		}
+	}
+	err = dry.AlterColumnType(&ddlModel{}, "missing")
+	if err == nil {
+		t.Error("expected an error for a missing column")
+	}
+}
@@ -0,0 +1,337 @@
+package orange
+
+import (
+	"errors"
+	"fmt"
+)
+
+// DiffKind is the kind of difference between a registered model and the live
+// database schema.
+type DiffKind string
+
+// Kinds of differences.
+const (
+	MissingTable  DiffKind = "missing table"
+	MissingColumn DiffKind = "missing column"
+	ExtraColumn   DiffKind = "extra column"
+	TypeMismatch  DiffKind = "type mismatch"
+	NullMismatch  DiffKind = "nullability mismatch"
+	MissingIndex  DiffKind = "missing index"
+)
+
+// Difference is a mismatch between a registered model and the live database
+// schema.
+type Difference struct {
+	Kind   DiffKind
+	Table  string
+	Column string
+	Index  string
+
+	// Expected is what the model declares and Actual is what the database has,
+	// they are only set for type and nullability mismatches.
+	Expected string
+	Actual   string
+}
+
+func (d Difference) String() string {
+	switch d.Kind {
+	case MissingTable:
+		return fmt.Sprintf("%s: %s", d.Kind, d.Table)
+	case MissingIndex:
+		return fmt.Sprintf("%s: %s on %s", d.Kind, d.Index, d.Table)
+	case TypeMismatch, NullMismatch:
+		return fmt.Sprintf("%s: %s.%s expected %s got %s", d.Kind, d.Table, d.Column, d.Expected, d.Actual)
+	}
+	return fmt.Sprintf("%s: %s.%s", d.Kind, d.Table, d.Column)
+}
+
+// Destructive returns true if the database can not be made to match the model
+// without altering or dropping existing data.
+func (d Difference) Destructive() bool {
+	switch d.Kind {
+	case ExtraColumn, TypeMismatch, NullMismatch:
+		return true
+	}
+	return false
+}
+
+// ErrNoModels is returned by Verify and SchemaSQL when no models are registered,
+// since there is nothing to compare or dump.
+var ErrNoModels = errors.New("no models registered")
+
+// Verify compares the registered models with the live database schema and
+// returns the differences, an empty list means the database matches the models.
+// Use it at startup to fail fast against a database that was not migrated.
+//
+//	diffs, err := db.Verify()
+//	if err != nil {
+//		log.Fatal(err)
+//	}
+//	for _, v := range diffs {
+//		log.Println(v)
+//	}
+func (s *SQL) Verify() ([]Difference, error) {
+	if !s.hasModels() {
+		return nil, ErrNoModels
+	}
+	schema, err := s.Inspect()
+	if err != nil {
+		return nil, err
+	}
+	return s.diff(schema)
+}
+
+// diff returns the differences between the registered models and schema.
+func (s *SQL) diff(schema *Schema) ([]Difference, error) {
+	models, _, err := s.sortedModels()
+	if err != nil {
+		return nil, err
+	}
+	var rst []Difference
+	for _, m := range models {
+		live := schema.Table(m.Name())
+		if live == nil {
+			rst = append(rst, Difference{Kind: MissingTable, Table: m.Name()})
+			continue
+		}
+		d, err := s.diffTable(m, live)
+		if err != nil {
+			return nil, err
+		}
+		rst = append(rst, d...)
+	}
+	return rst, nil
+}
+
+// diffTable returns the differences between t and the live table.
+func (s *SQL) diffTable(t Table, live *TableSchema) ([]Difference, error) {
+	fields, err := t.Fields()
+	if err != nil {
+		return nil, err
+	}
+	var rst []Difference
+	columns := make(map[string]bool)
+	for _, f := range fields {
+		name := f.ColumnName()
+		columns[name] = true
+		col := live.Column(name)
+		if col == nil {
+			rst = append(rst, Difference{Kind: MissingColumn, Table: t.Name(), Column: name})
+			continue
+		}
+		typ, err := s.adopter.ColumnType(f)
+		if err != nil {
+			return nil, err
+		}
+		expect, actual := s.adopter.NormalizeType(typ), s.adopter.NormalizeType(col.Type)
+		if expect != actual {
+			rst = append(rst, Difference{
+				Kind: TypeMismatch, Table: t.Name(), Column: name,
+				Expected: expect, Actual: actual,
+			})
+		}
+		if nullable(t, f) != col.Nullable {
+			rst = append(rst, Difference{
+				Kind: NullMismatch, Table: t.Name(), Column: name,
+				Expected: nullability(nullable(t, f)), Actual: nullability(col.Nullable),
+			})
+		}
+	}
+	for _, v := range live.Columns {
+		if !columns[v.Name] {
+			rst = append(rst, Difference{Kind: ExtraColumn, Table: t.Name(), Column: v.Name})
+		}
+	}
+	for _, v := range tableIndexes(t) {
+		if live.Index(v.Name) == nil {
+			rst = append(rst, Difference{Kind: MissingIndex, Table: t.Name(), Index: v.Name})
+		}
+	}
+	return rst, nil
+}
+
+// nullable returns true if the column of f in table t accepts NULL.
+func nullable(t Table, f Field) bool {
+	if hasFlag(f, specialTags.notNull) {
+		return false
+	}
+	for _, v := range primaryKeys(t) {
+		if v.ColumnName() == f.ColumnName() {
+			return false
+		}
+	}
+	return true
+}
+
+func nullability(null bool) string {
+	if null {
+		return "NULL"
+	}
+	return "NOT NULL"
+}
+
+// AutomigrateReport brings the database schema in line with the registered
+// models. Missing tables are created, missing columns and indexes are added to
+// existing tables. Changes that would alter or drop existing data are not
+// executed, they are returned instead.
+//
+// The migration lock is held while the schema is changed, see LockTimeout.
+func (s *SQL) AutomigrateReport() ([]Difference, error) {
+	var skipped []Difference
+	err := s.withLock(migrationLock, func(locked *SQL) error {
+		var err error
+		skipped, err = locked.automigrate()
+		return err
+	})
+	return skipped, err
+}
+
+func (s *SQL) automigrate() ([]Difference, error) {
+	schema, err := s.Inspect()
+	if err != nil {
+		return nil, err
+	}
+	models, cyclic, err := s.sortedModels()
+	if err != nil {
+		return nil, err
+	}
+	var skipped []Difference
+	var deferred []Table // foreign keys added once all tables exist.
+	for _, m := range models {
+		m, err = s.resolve(m)
+		if err != nil {
+			return nil, err
+		}
+		var keys []ForeignKey
+		m, keys = cyclic.split(m)
+		live := schema.Table(m.Name())
+		if live == nil {
+			err = s.createTable(m)
+			if err != nil {
+				return nil, err
+			}
+			deferred = append(deferred, &relatedTable{Table: m, keys: keys})
+			continue
+		}
+		diffs, err := s.diffTable(m, live)
+		if err != nil {
+			return nil, err
+		}
+		for _, d := range diffs {
+			switch d.Kind {
+			case MissingColumn:
+				err = s.addColumn(m, d.Column)
+				for _, k := range keys {
+					if k.Column == d.Column {
+						deferred = append(deferred, &relatedTable{Table: m, keys: []ForeignKey{k}})
+					}
+				}
+			case MissingIndex:
+				for _, idx := range tableIndexes(m) {
+					if idx.Name == d.Index {
+						err = s.CreateIndex(idx)
+					}
+				}
+			default:
+				skipped = append(skipped, d)
+			}
+			if err != nil {
+				return nil, err
+			}
+		}
+	}
+	for _, t := range deferred {
+		err = s.addForeignKeys(t)
+		if err != nil {
+			return nil, err
+		}
+	}
+	return skipped, nil
+}
+
+// CreateTables creates the tables of the registered models and their indexes.
+// Tables are created after the tables they reference. Unlike Automigrate the
+// live database is not inspected, with DryRun it collects the DDL of the
+// models. Foreign keys of tables which reference each other are added after
+// the tables are created.
+func (s *SQL) CreateTables() error {
+	models, cyclic, err := s.sortedModels()
+	if err != nil {
+		return err
+	}
+	var deferred []Table
+	for _, m := range models {
+		m, err = s.resolve(m)
+		if err != nil {
+			return err
+		}
+		var keys []ForeignKey
+		m, keys = cyclic.split(m)
+		err = s.createTable(m)
+		if err != nil {
+			return err
+		}
+		deferred = append(deferred, &relatedTable{Table: m, keys: keys})
+	}
+	for _, t := range deferred {
+		err = s.addForeignKeys(t)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// createTable creates the table t and its indexes.
+func (s *SQL) createTable(t Table) error {
+	query, err := s.adopter.Create(t)
+	if err != nil {
+		return err
+	}
+	_, err = s.exec(&statement{op: OpMigrate, table: t.Name(), query: query})
+	if err != nil {
+		return err
+	}
+	for _, idx := range tableIndexes(t) {
+		err = s.CreateIndex(idx)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// addForeignKeys adds the foreign keys of t to the database table.
+func (s *SQL) addForeignKeys(t Table) error {
+	for _, v := range foreignKeys(t) {
+		query, err := s.adopter.AddForeignKey(t.Name(), v)
+		if err != nil {
+			return err
+		}
+		_, err = s.exec(&statement{op: OpMigrate, table: t.Name(), query: query})
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// addColumn adds the column of t to the database table.
+func (s *SQL) addColumn(t Table, column string) error {
+	fields, err := t.Fields()
+	if err != nil {
+		return err
+	}
+	for _, f := range fields {
+		if f.ColumnName() != column {
+			continue
+		}
+		query, err := s.adopter.AddColumn(t, f)
+		if err != nil {
+			return err
+		}
+		_, err = s.exec(&statement{op: OpMigrate, table: t.Name(), query: query})
+		return err
+	}
+	return fmt.Errorf("table %s has no column %s", t.Name(), column)
+}
@@ -0,0 +1,128 @@
+package orange
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestSQL_DiffTable(t *testing.T) {
+	db, err := Open("postgres", testDB.ps)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tab, err := loadTable(&indexedModel{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	live := &TableSchema{
+		Name: "indexed_model",
+		Columns: []*ColumnSchema{
+			{Name: "id", Type: "bigint"},
+			{Name: "email", Type: "character varying(64)", Nullable: true},
+			{Name: "first_name", Type: "text", Nullable: true},
+			{Name: "nickname", Type: "text", Nullable: true},
+		},
+		Indexes: []Index{{Name: "idx_full_name"}, {Name: "idx_tags"}},
+	}
+	diffs, err := db.diffTable(tab, live)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expect := []Difference{
+		{Kind: TypeMismatch, Table: "indexed_model", Column: "email", Expected: "text", Actual: "character varying(64)"},
+		{Kind: MissingColumn, Table: "indexed_model", Column: "last_name"},
+		{Kind: MissingColumn, Table: "indexed_model", Column: "tags"},
+		{Kind: ExtraColumn, Table: "indexed_model", Column: "nickname"},
+		{Kind: MissingIndex, Table: "indexed_model", Index: "idx_indexed_model_email"},
+		{Kind: MissingIndex, Table: "indexed_model", Index: "idx_indexed_model_lower_email"},
+	}
+	if !reflect.DeepEqual(diffs, expect) {
+		t.Errorf("expected %v got %v", expect, diffs)
+	}
+	for _, v := range diffs {
+		destructive := v.Kind == TypeMismatch || v.Kind == ExtraColumn
+		if v.Destructive() != destructive {
+			t.Errorf("expected destructive to be %v for %s", destructive, v)
+		}
+	}
+}
+
+func TestSQL_Diff(t *testing.T) {
+	db, err := Open("postgres", testDB.ps)
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = db.Register(&author{}, &article{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	schema := &Schema{Tables: []*TableSchema{
+		{
+			Name: "author",
+			Columns: []*ColumnSchema{
+				{Name: "id", Type: "integer"},
+				{Name: "name", Type: "text"},
+			},
+		},
+	}}
+	diffs, err := db.diff(schema)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expect := []Difference{
+		{Kind: TypeMismatch, Table: "author", Column: "id", Expected: "bigint", Actual: "integer"},
+		{Kind: NullMismatch, Table: "author", Column: "name", Expected: "NULL", Actual: "NOT NULL"},
+		{Kind: MissingTable, Table: "article"},
+	}
+	if !reflect.DeepEqual(diffs, expect) {
+		t.Errorf("expected %v got %v", expect, diffs)
+	}
+}
+
+func TestPostgres_NormalizeType(t *testing.T) {
+	p := &postgresql{}
+	sample := []struct {
+		typ, expect string
+	}{
+		{"serial", "integer"},
+		{"bigserial", "bigint"},
+		{"VARCHAR(64)", "character varying(64)"},
+		{"numeric(12, 2)", "numeric(12,2)"},
+		{"timestamptz", "timestamp with time zone"},
+		{"timestamp  with time zone", "timestamp with time zone"},
+		{"text", "text"},
+		{"int8[]", "bigint[]"},
+		{"TEXT[]", "text[]"},
+	}
+	for _, v := range sample {
+		if n := p.NormalizeType(v.typ); n != v.expect {
+			t.Errorf("expected %s got %s", v.expect, n)
+		}
+	}
+}
+
+func TestPostgres_AddColumn(t *testing.T) {
+	db, err := Open("postgres", testDB.ps)
+	if err != nil {
+		t.Fatal(err)
+	}
+	_ = db.Register(&author{}, &article{})
+	tab, err := loadTable(&article{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	tab, err = db.resolve(tab)
+	if err != nil {
+		t.Fatal(err)
+	}
+	fields, _ := tab.Fields()
+	p := &postgresql{}
+	query, err := p.AddColumn(tab, fields[1])
+	if err != nil {
+		t.Fatal(err)
+	}
+	expect := "ALTER TABLE article ADD COLUMN IF NOT EXISTS author_id bigint REFERENCES author (id) ON DELETE CASCADE;"
+	if query != expect {
+		t.Errorf("expected %s got %s", expect, query)
+	}
+}
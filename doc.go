@@ -8,4 +8,78 @@
 //
 // Use this as a way to dive into Golang, a quick easy way to interact with your
 // database.Enjoy.
+//
+// Struct tags
+//
+// Fields can be customized with the sql struct tag. The tag is a semicolon
+// separated list of options, an option is either a key or a key:value pair.
+//	type user struct {
+//		ID   int64
+//		Name string `sql:"name:user_name;type:varchar(64)"`
+//		Temp string `sql:"-"`
+//	}
+//
+// The name option sets the column name, and type sets the column type used
+// when creating the table. Fields tagged with - are ignored.
+//
+// Without the type option the column type comes from the type registry of the
+// adopter, which knows the numeric types, bool, string, []byte and time.Time.
+// Other types are added with RegisterType, or by implementing SQLTyper.
+//	db.RegisterType(Money{}, "numeric(20,9)")
+//
+// Types implementing driver.Valuer and sql.Scanner are written with their Value
+// method and scanned with their Scan method, zero values included. They are
+// nullable when the Value of their zero value is nil.
+//
+// Fields tagged with json, or whose type implements JSONColumn, are marshalled
+// to JSON on write and unmarshalled on scan. On postgres they are jsonb columns,
+// which can be queried with WhereJSONContains and WhereJSONField.
+//	Tags []string `sql:"json"`
+//
+// On postgres slices of strings, numbers and booleans are arrays e.g []string
+// is text[], UUID and [16]byte are uuid, net.IP is inet, big.Rat is numeric and
+// time.Duration is interval. Arrays are queried with WhereAny and
+// WhereArrayContains.
+//	db.WhereArrayContains("tags", []string{"go"})
+//
+// Before time.Duration was mapped to interval it was stored as bigint
+// nanoseconds. Verify reports such columns as type mismatches, AlterColumnType
+// converts them to interval keeping their values. Registering bigint keeps
+// using the old columns.
+//	err := db.AlterColumnType(&Job{}, "timeout")
+//	// ALTER TABLE job ALTER COLUMN timeout TYPE interval USING timeout / 1000 * interval '1 microsecond';
+//	db.RegisterType(time.Duration(0), "bigint")
+//
+// Columns which can be NULL are mapped to pointers or to types like
+// sql.NullString. Unlike other fields, which are only inserted when they are not
+// zero, nullable fields are always inserted and nil is written as NULL. On
+// insert a nil field tagged with a default is left out, so that the default is
+// used. Update only writes the fields which are set, columns are set to NULL by
+// listing them with UpdateColumns. Scanning NULL into a field which is not
+// nullable fails.
+//
+// Column constraints are declared with the following options
+//	not null		the column can not be NULL
+//	unique			values of the column are unique
+//	default:expr		the default value of the column
+//	check:expr		a CHECK constraint on the column
+//	size:n			length of string columns e.g varchar(n)
+//	precision:p,s		precision and scale of numeric columns, for float,
+//				string and decimal fields
+//
+// Indexes are declared with the index and unique_index options, fields tagged
+// with the same index name e.g index:idx_full_name make up a composite index.
+// Models can declare more advanced indexes by implementing Indexer.
+//
+// A field can reference another registered model with the relation option,
+// the referenced column defaults to the primary key of the model.
+//	AuthorID int64 `sql:"relation:Author(id);on_delete:cascade;on_update:cascade"`
+//
+// Automigrate creates FOREIGN KEY constraints for relations, tables are created
+// after the tables they reference. When tables reference each other the
+// constraints closing the cycle are added once the tables are created.
+//
+// The primary key is made of the fields tagged with pk, when no field is tagged
+// the field whose column name is id is used. A primary key made of a single
+// integer field is auto incremented.
 package orange
@@ -0,0 +1,63 @@
+package orange
+
+import (
+	"database/sql"
+	"sync"
+)
+
+// Statement is a sql statement with its arguments.
+type Statement struct {
+	Query string
+	Args  []interface{}
+}
+
+// dryRun collects the statements of a dry run.
+type dryRun struct {
+	mu         sync.Mutex
+	statements []Statement
+}
+
+func (d *dryRun) record(st *statement) {
+	d.mu.Lock()
+	d.statements = append(d.statements, Statement{Query: st.query, Args: st.args})
+	d.mu.Unlock()
+}
+
+// dryResult is the result of a statement which was not executed.
+type dryResult struct{}
+
+func (dryResult) LastInsertId() (int64, error) { return 0, nil }
+func (dryResult) RowsAffected() (int64, error) { return 0, nil }
+
+var _ sql.Result = dryResult{}
+
+// DryRun returns a copy of s which collects the statements that change the
+// database instead of executing them. Create, Update, Delete, Exec, Automigrate
+// and migrations are collected, queries which only read from the database are
+// still executed e.g Automigrate inspects the live schema. Hooks are called for
+// the collected statements as if they were executed.
+//
+//	dry := db.DryRun()
+//	err := dry.Automigrate()
+//	...
+//	for _, v := range dry.Statements() {
+//		fmt.Println(v.Query)
+//	}
+func (s *SQL) DryRun() *SQL {
+	dup := s.Copy()
+	dup.dryRun = &dryRun{}
+	return dup
+}
+
+// Statements returns the statements collected by the dry run in the order they
+// would have been executed. It returns nil if s is not a dry run.
+func (s *SQL) Statements() []Statement {
+	if s.dryRun == nil {
+		return nil
+	}
+	s.dryRun.mu.Lock()
+	defer s.dryRun.mu.Unlock()
+	rst := make([]Statement, len(s.dryRun.statements))
+	copy(rst, s.dryRun.statements)
+	return rst
+}
@@ -0,0 +1,101 @@
+package orange
+
+import (
+	"reflect"
+	"testing"
+)
+
+type dryModel struct {
+	ID   int64
+	Name string
+}
+
+func TestSQL_DryRun(t *testing.T) {
+	db, err := Open("postgres", testDB.ps)
+	if err != nil {
+		t.Fatal(err)
+	}
+	dry := db.DryRun()
+	err = dry.Create(&dryModel{ID: 1, Name: "gernest"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = dry.Delete(&dryModel{ID: 1})
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, err = dry.Exec("DROP TABLE dry_model;")
+	if err != nil {
+		t.Fatal(err)
+	}
+	expect := []Statement{
+		{Query: "INSERT INTO dry_model (id, name) VALUES (1, 'gernest');"},
+		{Query: "DELETE FROM dry_model WHERE id=$1"},
+		{Query: "DROP TABLE dry_model;"},
+	}
+	got := dry.Statements()
+	if len(got) != len(expect) {
+		t.Fatalf("expected %d statements got %d: %v", len(expect), len(got), got)
+	}
+	for k, v := range expect {
+		if got[k].Query != v.Query {
+			t.Errorf("expected %s got %s", v.Query, got[k].Query)
+		}
+	}
+	if db.Statements() != nil {
+		t.Error("expected no statements outside the dry run")
+	}
+}
+
+func TestMigrator_DryRun(t *testing.T) {
+	db, err := Open("postgres", testDB.ps)
+	if err != nil {
+		t.Fatal(err)
+	}
+	dry := db.DryRun()
+	err = dry.Migrations().Add(Migration{
+		Version: 1,
+		Name:    "create_users",
+		Up: func(s *SQL) error {
+			_, err := s.Exec("CREATE TABLE users (id serial);")
+			return err
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = dry.Migrations().Migrate()
+	if err != nil {
+		t.Fatal(err)
+	}
+	got := dry.Statements()
+	if len(got) != 3 {
+		t.Fatalf("expected 3 statements got %d: %v", len(got), got)
+	}
+	if got[1].Query != "CREATE TABLE users (id serial);" {
+		t.Errorf("expected the migration got %s", got[1].Query)
+	}
+	insert := "INSERT INTO schema_migrations (version, name, applied_at) VALUES ($1, $2, $3);"
+	if got[2].Query != insert {
+		t.Errorf("expected %s got %s", insert, got[2].Query)
+	}
+	if !reflect.DeepEqual(got[2].Args[:2], []interface{}{int64(1), "create_users"}) {
+		t.Errorf("expected [1 create_users] got %v", got[2].Args[:2])
+	}
+}
+
+func TestMigrator_DryRunError(t *testing.T) {
+	db, err := Open("postgres", "host=/nonexistent/orange dbname=orange_test sslmode=disable")
+	if err != nil {
+		t.Fatal(err)
+	}
+	dry := db.DryRun()
+	err = dry.Migrations().Add(Migration{Version: 1, Name: "noop", Up: func(s *SQL) error { return nil }})
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = dry.Migrations().Migrate()
+	if err == nil {
+		t.Error("expected the connection error")
+	}
+}
@@ -0,0 +1,286 @@
+package orange
+
+import (
+	"bytes"
+	"fmt"
+	"go/format"
+	"sort"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+// GenerateModels returns Go source code of package pkg with a model for every
+// table of the live database, or only for tables when they are given. The
+// fields of the models are tagged with the column names, types, constraints,
+// indexes and relations of the tables.
+func (s *SQL) GenerateModels(pkg string, tables ...string) ([]byte, error) {
+	schema, err := s.Inspect()
+	if err != nil {
+		return nil, err
+	}
+	if len(tables) > 0 {
+		selected := &Schema{}
+		for _, v := range tables {
+			t := schema.Table(v)
+			if t == nil {
+				return nil, fmt.Errorf("table %s does not exist", v)
+			}
+			selected.Tables = append(selected.Tables, t)
+		}
+		schema = selected
+	}
+	return generateModels(s.adopter, schema, pkg)
+}
+
+// generateModels returns the formatted source of the models for the tables of
+// schema.
+func generateModels(a Adopter, schema *Schema, pkg string) ([]byte, error) {
+	body := &bytes.Buffer{}
+	imports := make(map[string]bool)
+	for _, t := range schema.Tables {
+		err := generateModel(body, a, t, imports)
+		if err != nil {
+			return nil, err
+		}
+	}
+	buf := &bytes.Buffer{}
+	fmt.Fprintf(buf, "// Code generated by orange gen. DO NOT EDIT.\n\npackage %s\n\n", pkg)
+	if len(imports) > 0 {
+		var std, other []string
+		for k := range imports {
+			if strings.Contains(k, ".") {
+				other = append(other, k)
+				continue
+			}
+			std = append(std, k)
+		}
+		sort.Strings(std)
+		sort.Strings(other)
+		_, _ = buf.WriteString("import (\n")
+		for _, v := range std {
+			fmt.Fprintf(buf, "\t%q\n", v)
+		}
+		if len(std) > 0 && len(other) > 0 {
+			_, _ = buf.WriteString("\n")
+		}
+		for _, v := range other {
+			fmt.Fprintf(buf, "\t%q\n", v)
+		}
+		_, _ = buf.WriteString(")\n\n")
+	}
+	_, _ = buf.Write(body.Bytes())
+	return format.Source(buf.Bytes())
+}
+
+// generateModel writes the model of t to w, the packages used by the model are
+// added to imports.
+func generateModel(w *bytes.Buffer, a Adopter, t *TableSchema, imports map[string]bool) error {
+	name := modelIdent(t.Name)
+	fmt.Fprintf(w, "// %s is the model of the %s table.\n", name, t.Name)
+	if tabulizeName(name) != t.Name {
+		fmt.Fprintf(w, "//\n// Note that the table of this model is %s.\n", tabulizeName(name))
+	}
+	fmt.Fprintf(w, "type %s struct {\n", name)
+	tags := columnTags(t)
+	used := make(map[string]bool)
+	for _, c := range t.Columns {
+		typ, options := a.GoType(c)
+		if typ == "" {
+			return fmt.Errorf("no Go type for %s.%s of type %s", t.Name, c.Name, c.Type)
+		}
+		if c.Nullable && !strings.HasPrefix(typ, "[]") {
+			typ = "*" + typ
+		}
+		if strings.Contains(typ, "time.") {
+			imports["time"] = true
+		}
+		if strings.Contains(typ, "net.") {
+			imports["net"] = true
+		}
+		if strings.Contains(typ, "orange.") {
+			imports["github.com/gernest/orange"] = true
+		}
+		field := fieldIdent(c.Name, used)
+		var opts []string
+		if tabulizeName(field) != c.Name {
+			opts = append(opts, specialTags.fieldName+":"+c.Name)
+		}
+		opts = append(opts, options...)
+		opts = append(opts, tags[c.Name]...)
+		fmt.Fprintf(w, "\t%s %s", field, typ)
+		if len(opts) > 0 {
+			fmt.Fprintf(w, " `sql:%s`", strconv.Quote(strings.Join(opts, ";")))
+		}
+		_, _ = w.WriteString("\n")
+	}
+	_, _ = w.WriteString("}\n\n")
+	indexes := methodIndexes(t)
+	if len(indexes) == 0 {
+		return nil
+	}
+	imports["github.com/gernest/orange"] = true
+	fmt.Fprintf(w, "// Indexes returns the indexes of the %s table which can not be declared with tags.\n", t.Name)
+	fmt.Fprintf(w, "func (m *%s) Indexes() []orange.Index {\n\treturn []orange.Index{\n", name)
+	for _, v := range indexes {
+		fmt.Fprintf(w, "\t\t{Name: %q, Columns: %#v", v.Name, v.Columns)
+		if v.Unique {
+			_, _ = w.WriteString(", Unique: true")
+		}
+		if v.Method != "" {
+			fmt.Fprintf(w, ", Method: %q", v.Method)
+		}
+		if v.Where != "" {
+			fmt.Fprintf(w, ", Where: %q", v.Where)
+		}
+		_, _ = w.WriteString("},\n")
+	}
+	_, _ = w.WriteString("\t}\n}\n\n")
+	return nil
+}
+
+// columnTags returns the tag options of the columns of t for the primary key,
+// constraints, indexes and relations.
+func columnTags(t *TableSchema) map[string][]string {
+	rst := make(map[string][]string)
+	pk := make(map[string]bool)
+	for _, v := range t.PrimaryKey {
+		pk[v] = true
+		rst[v] = append(rst[v], specialTags.primaryKey)
+	}
+	for _, c := range t.Columns {
+		if !c.Nullable && !pk[c.Name] {
+			rst[c.Name] = append(rst[c.Name], specialTags.notNull)
+		}
+		if c.Default != "" && !strings.HasPrefix(c.Default, "nextval(") && tagSafe(c.Default) {
+			rst[c.Name] = append(rst[c.Name], specialTags.defaultValue+":"+c.Default)
+		}
+	}
+	for _, v := range tagIndexes(t) {
+		if v.Unique && len(v.Columns) == 1 && v.Name == t.Name+"_"+v.Columns[0]+"_key" {
+			rst[v.Columns[0]] = append(rst[v.Columns[0]], specialTags.unique)
+			continue
+		}
+		key := specialTags.index
+		if v.Unique {
+			key = specialTags.uniqueIndex
+		}
+		for _, c := range v.Columns {
+			rst[c] = append(rst[c], key+":"+v.Name)
+		}
+	}
+	count := make(map[string]int)
+	for _, v := range t.ForeignKeys {
+		count[v.Name]++
+	}
+	for _, v := range t.ForeignKeys {
+		if count[v.Name] > 1 {
+			// relations are single columns.
+			continue
+		}
+		opts := []string{specialTags.relation + ":" + v.RefTable + "(" + v.RefColumn + ")"}
+		if v.OnDelete != "" {
+			opts = append(opts, specialTags.onDelete+":"+strings.ToLower(v.OnDelete))
+		}
+		if v.OnUpdate != "" {
+			opts = append(opts, specialTags.onUpdate+":"+strings.ToLower(v.OnUpdate))
+		}
+		rst[v.Column] = append(rst[v.Column], opts...)
+	}
+	return rst
+}
+
+// tagIndexes returns the indexes of t which can be declared with tags. These
+// are plain indexes of columns listed in the order of the columns of the table.
+func tagIndexes(t *TableSchema) []Index {
+	var rst []Index
+	for _, v := range t.Indexes {
+		if isTagIndex(t, v) {
+			rst = append(rst, v)
+		}
+	}
+	return rst
+}
+
+// methodIndexes returns the indexes of t which have to be declared by the
+// Indexes method of the model.
+func methodIndexes(t *TableSchema) []Index {
+	var rst []Index
+	for _, v := range t.Indexes {
+		if !isTagIndex(t, v) && !isPrimaryIndex(t, v) {
+			rst = append(rst, v)
+		}
+	}
+	return rst
+}
+
+func isPrimaryIndex(t *TableSchema, idx Index) bool {
+	return idx.Unique && len(t.PrimaryKey) > 0 && strings.Join(idx.Columns, ",") == strings.Join(t.PrimaryKey, ",")
+}
+
+func isTagIndex(t *TableSchema, idx Index) bool {
+	if idx.Method != "" || idx.Where != "" || len(idx.Columns) == 0 || isPrimaryIndex(t, idx) {
+		return false
+	}
+	last := -1
+	for _, c := range idx.Columns {
+		pos := -1
+		for k, v := range t.Columns {
+			if v.Name == c {
+				pos = k
+			}
+		}
+		if pos <= last {
+			return false
+		}
+		last = pos
+	}
+	return true
+}
+
+// tagSafe returns true if v can be used as the value of a tag option.
+func tagSafe(v string) bool {
+	return !strings.ContainsAny(v, ";`")
+}
+
+// commonInitialisms are written in upper case in Go identifiers.
+var commonInitialisms = map[string]bool{
+	"api": true, "id": true, "ip": true, "json": true, "sql": true,
+	"url": true, "uri": true, "uuid": true, "http": true, "html": true,
+}
+
+// modelIdent returns the struct name for a table. Initialisms are not used,
+// so that the table name of the struct is name.
+func modelIdent(name string) string {
+	return camelCase(name, false)
+}
+
+// fieldIdent returns a unique field name for a column.
+func fieldIdent(column string, used map[string]bool) string {
+	name := camelCase(column, true)
+	for n := 2; used[name]; n++ {
+		name = fmt.Sprintf("%s%d", camelCase(column, true), n)
+	}
+	used[name] = true
+	return name
+}
+
+// camelCase converts a snake case name to an exported Go identifier.
+func camelCase(name string, initialisms bool) string {
+	buf := &bytes.Buffer{}
+	for _, part := range strings.FieldsFunc(name, func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	}) {
+		if initialisms && commonInitialisms[strings.ToLower(part)] {
+			_, _ = buf.WriteString(strings.ToUpper(part))
+			continue
+		}
+		r := []rune(part)
+		_, _ = buf.WriteString(strings.ToUpper(string(r[0])) + string(r[1:]))
+	}
+	rst := buf.String()
+	if rst == "" || !unicode.IsLetter([]rune(rst)[0]) {
+		rst = "X" + rst
+	}
+	return rst
+}
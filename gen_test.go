@@ -0,0 +1,90 @@
+package orange
+
+import (
+	"testing"
+)
+
+func TestGenerateModels(t *testing.T) {
+	schema := &Schema{Tables: []*TableSchema{
+		{
+			Name: "user_account",
+			Columns: []*ColumnSchema{
+				{Name: "id", Type: "bigint", Default: "nextval('user_account_id_seq'::regclass)"},
+				{Name: "email", Type: "character varying(64)"},
+				{Name: "balance", Type: "numeric(12,2)", Default: "0"},
+				{Name: "nick", Type: "text", Nullable: true},
+				{Name: "created_at", Type: "timestamp with time zone"},
+			},
+			PrimaryKey: []string{"id"},
+			Indexes: []Index{
+				{Name: "user_account_pkey", Table: "user_account", Columns: []string{"id"}, Unique: true},
+				{Name: "user_account_email_key", Table: "user_account", Columns: []string{"email"}, Unique: true},
+				{Name: "idx_nick", Table: "user_account", Columns: []string{"lower(nick)"}},
+			},
+		},
+		{
+			Name: "post",
+			Columns: []*ColumnSchema{
+				{Name: "id", Type: "integer"},
+				{Name: "owner_id", Type: "bigint"},
+				{Name: "meta", Type: "jsonb", Nullable: true},
+			},
+			PrimaryKey: []string{"id"},
+			ForeignKeys: []ForeignKey{
+				{Name: "post_owner_id_fkey", Column: "owner_id", RefTable: "user_account", RefColumn: "id", OnDelete: "CASCADE"},
+			},
+		},
+	}}
+	src, err := generateModels(&postgresql{}, schema, "models")
+	if err != nil {
+		t.Fatal(err)
+	}
+	expect := "// Code generated by orange gen. DO NOT EDIT.\n\n" +
+		"package models\n\n" +
+		"import (\n" +
+		"\t\"time\"\n\n" +
+		"\t\"github.com/gernest/orange\"\n" +
+		")\n\n" +
+		"// UserAccount is the model of the user_account table.\n" +
+		"type UserAccount struct {\n" +
+		"\tID        int64   `sql:\"pk\"`\n" +
+		"\tEmail     string  `sql:\"size:64;not null;unique\"`\n" +
+		"\tBalance   float64 `sql:\"precision:12,2;not null;default:0\"`\n" +
+		"\tNick      *string\n" +
+		"\tCreatedAt time.Time `sql:\"not null\"`\n" +
+		"}\n\n" +
+		"// Indexes returns the indexes of the user_account table which can not be declared with tags.\n" +
+		"func (m *UserAccount) Indexes() []orange.Index {\n" +
+		"\treturn []orange.Index{\n" +
+		"\t\t{Name: \"idx_nick\", Columns: []string{\"lower(nick)\"}},\n" +
+		"\t}\n" +
+		"}\n\n" +
+		"// Post is the model of the post table.\n" +
+		"type Post struct {\n" +
+		"\tID      int     `sql:\"pk\"`\n" +
+		"\tOwnerID int64   `sql:\"name:owner_id;not null;relation:user_account(id);on_delete:cascade\"`\n" +
+		"\tMeta    *string `sql:\"type:jsonb\"`\n" +
+		"}\n"
+	if string(src) != expect {
+		t.Errorf("expected %s got %s", expect, src)
+	}
+}
+
+func TestCamelCase(t *testing.T) {
+	sample := []struct {
+		name, expect, field string
+	}{
+		{"user_account", "UserAccount", "UserAccount"},
+		{"api_key", "ApiKey", "APIKey"},
+		{"owner_id", "OwnerId", "OwnerID"},
+		{"2fa", "X2fa", "X2fa"},
+	}
+	for _, v := range sample {
+		if got := camelCase(v.name, false); got != v.expect {
+			t.Errorf("expected %s got %s", v.expect, got)
+		}
+		if got := fieldIdent(v.name, map[string]bool{}); got != v.field {
+			t.Errorf("expected %s got %s", v.field, got)
+		}
+	}
+}
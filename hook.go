@@ -0,0 +1,139 @@
+package orange
+
+import (
+	"database/sql"
+	"strings"
+	"time"
+)
+
+// Operation is the kind of statement that is executed.
+type Operation string
+
+// Operations reported to hooks.
+const (
+	OpSelect  Operation = "select"
+	OpCreate  Operation = "create"
+	OpUpdate  Operation = "update"
+	OpDelete  Operation = "delete"
+	OpMigrate Operation = "migrate"
+	OpExec    Operation = "exec"
+)
+
+// QueryEvent describes a statement executed by orange. The same event is
+// passed to BeforeQuery and AfterQuery, Duration and Err are only set when
+// AfterQuery is called.
+type QueryEvent struct {
+	Operation Operation
+	Table     string
+	Query     string
+	Args      []interface{}
+	Duration  time.Duration
+	Err       error
+}
+
+// Hook is an interface for observing statements executed by orange. This
+// makes it possible to plug in tracing and metrics without orange depending on
+// them.
+type Hook interface {
+	BeforeQuery(*QueryEvent)
+	AfterQuery(*QueryEvent)
+}
+
+// AddHook registers h, hooks are called in the order they were added.
+func (s *SQL) AddHook(h Hook) *SQL {
+	// copies share the hooks, appending to a full slice keeps the hooks of
+	// other copies intact.
+	s.hooks = append(s.hooks[:len(s.hooks):len(s.hooks)], h)
+	return s
+}
+
+// statement is a query that is about to be executed.
+type statement struct {
+	op    Operation
+	table string
+	model string
+	query string
+	args  []interface{}
+}
+
+// newStatement returns a statement for a raw query. The operation is guessed
+// from the first keyword of the query.
+func newStatement(query string, args []interface{}) *statement {
+	return &statement{op: operation(query), query: query, args: args}
+}
+
+// operation returns the kind of operation that query performs.
+func operation(query string) Operation {
+	fields := strings.Fields(query)
+	if len(fields) == 0 {
+		return OpExec
+	}
+	switch strings.ToUpper(fields[0]) {
+	case "SELECT":
+		return OpSelect
+	case "INSERT":
+		return OpCreate
+	case "UPDATE":
+		return OpUpdate
+	case "DELETE":
+		return OpDelete
+	case "CREATE", "DROP", "ALTER":
+		return OpMigrate
+	}
+	return OpExec
+}
+
+// before tags st with comments and notifies hooks that it is about to be
+// executed.
+func (s *SQL) before(st *statement) *QueryEvent {
+	st.query = s.annotate(st)
+	e := &QueryEvent{
+		Operation: st.op,
+		Table:     st.table,
+		Query:     st.query,
+		Args:      st.args,
+	}
+	for _, h := range s.hooks {
+		h.BeforeQuery(e)
+	}
+	return e
+}
+
+// after notifies hooks and the slow query reporter that st was executed.
+func (s *SQL) after(e *QueryEvent, start time.Time, err error) {
+	e.Duration = time.Since(start)
+	e.Err = err
+	for _, h := range s.hooks {
+		h.AfterQuery(e)
+	}
+	s.observe(e.Query, e.Args, e.Duration)
+}
+
+func (s *SQL) exec(st *statement) (sql.Result, error) {
+	e := s.before(st)
+	start := time.Now()
+	if s.dryRun != nil {
+		s.dryRun.record(st)
+		s.after(e, start, nil)
+		return dryResult{}, nil
+	}
+	rst, err := s.conn().ExecContext(s.context(), st.query, st.args...)
+	s.after(e, start, err)
+	return rst, err
+}
+
+func (s *SQL) query(st *statement) (*sql.Rows, error) {
+	e := s.before(st)
+	start := time.Now()
+	rows, err := s.conn().QueryContext(s.context(), st.query, st.args...)
+	s.after(e, start, err)
+	return rows, err
+}
+
+func (s *SQL) queryRow(st *statement) *sql.Row {
+	e := s.before(st)
+	start := time.Now()
+	row := s.conn().QueryRowContext(s.context(), st.query, st.args...)
+	s.after(e, start, row.Err())
+	return row
+}
@@ -0,0 +1,103 @@
+package orange
+
+import "testing"
+
+type recordHook struct {
+	before, after []*QueryEvent
+}
+
+func (r *recordHook) BeforeQuery(e *QueryEvent) {
+	r.before = append(r.before, e)
+}
+
+func (r *recordHook) AfterQuery(e *QueryEvent) {
+	r.after = append(r.after, e)
+}
+
+func TestOperation(t *testing.T) {
+	sample := []struct {
+		query  string
+		expect Operation
+	}{
+		{"SELECT * FROM golangster;", OpSelect},
+		{"insert into golangster (name) VALUES ('x');", OpCreate},
+		{"UPDATE golangster SET name='x'", OpUpdate},
+		{"DELETE FROM golangster", OpDelete},
+		{"CREATE TABLE IF NOT EXISTS golangster (id serial);", OpMigrate},
+		{"VACUUM", OpExec},
+		{"", OpExec},
+	}
+	for _, v := range sample {
+		op := operation(v.query)
+		if op != v.expect {
+			t.Errorf("expected %s got %s", v.expect, op)
+		}
+	}
+}
+
+func TestSQL_AddHook(t *testing.T) {
+	db, err := Open("postgres", testDB.ps)
+	if err != nil {
+		t.Fatal(err)
+	}
+	h := &recordHook{}
+	db.AddHook(h)
+	_ = db.Register(&golangster{})
+	_, execErr := db.exec(&statement{op: OpCreate, table: "golangster", query: "SELECT 1;"})
+	if len(h.before) != 1 || len(h.after) != 1 {
+		t.Fatalf("expected hooks to be called once got %d %d", len(h.before), len(h.after))
+	}
+	e := h.after[0]
+	if e != h.before[0] {
+		t.Error("expected the same event before and after the query")
+	}
+	if e.Table != "golangster" {
+		t.Errorf("expected golangster got %s", e.Table)
+	}
+	if e.Operation != OpCreate {
+		t.Errorf("expected %s got %s", OpCreate, e.Operation)
+	}
+	if e.Err != execErr {
+		t.Errorf("expected %v got %v", execErr, e.Err)
+	}
+}
+
+func TestSQL_AddHookCopy(t *testing.T) {
+	db, err := Open("postgres", testDB.ps)
+	if err != nil {
+		t.Fatal(err)
+	}
+	db.hooks = make([]Hook, 0, 4)
+	db.AddHook(&recordHook{})
+	a, b := &recordHook{}, &recordHook{}
+	first := db.Copy().AddHook(a)
+	second := db.Copy().AddHook(b)
+	if len(db.hooks) != 1 {
+		t.Errorf("expected 1 hook got %d", len(db.hooks))
+	}
+	if first.hooks[1] != a {
+		t.Error("expected the hook of the first copy to be kept")
+	}
+	if second.hooks[1] != b {
+		t.Error("expected the hook of the second copy to be kept")
+	}
+}
+
+func TestSQL_DryRunHooks(t *testing.T) {
+	db, err := Open("postgres", testDB.ps)
+	if err != nil {
+		t.Fatal(err)
+	}
+	h := &recordHook{}
+	dry := db.DryRun().AddHook(h)
+	_, err = dry.Exec("DROP TABLE golangster;")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(h.before) != 1 || len(h.after) != 1 {
+		t.Fatalf("expected hooks to be called once got %d %d", len(h.before), len(h.after))
+	}
+	if h.after[0].Query != "DROP TABLE golangster;" {
+		t.Errorf("expected %s got %s", "DROP TABLE golangster;", h.after[0].Query)
+	}
+}
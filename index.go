@@ -0,0 +1,139 @@
+package orange
+
+import (
+	"errors"
+	"strings"
+	"unicode"
+)
+
+// Index describes a database index.
+type Index struct {
+	// Name is the name of the index, when empty a name is derived from the
+	// table and the columns.
+	Name string
+
+	// Table is the name of the indexed table.
+	Table string
+
+	// Columns are the indexed columns, they can also be expressions like
+	// lower(email).
+	Columns []string
+
+	Unique bool
+
+	// Method is the index method e.g btree or gin. The database default is used
+	// when it is empty.
+	Method string
+
+	// Where is the predicate of a partial index.
+	Where string
+}
+
+// Indexer is implemented by models and tables that declare indexes.
+//
+//	func (u *user) Indexes() []orange.Index {
+//		return []orange.Index{
+//			{Columns: []string{"lower(email)"}, Unique: true},
+//			{Columns: []string{"tags"}, Method: "gin"},
+//		}
+//	}
+//
+// The Table of the indexes declared by a model is set to the table of the
+// model.
+type Indexer interface {
+	Indexes() []Index
+}
+
+// indexName returns the name of idx, a name is generated from the table and
+// the columns when idx has no name.
+func indexName(idx Index) string {
+	if idx.Name != "" {
+		return idx.Name
+	}
+	parts := []string{"idx", idx.Table}
+	for _, v := range idx.Columns {
+		parts = append(parts, strings.Trim(strings.Map(func(r rune) rune {
+			if unicode.IsLetter(r) || unicode.IsDigit(r) {
+				return unicode.ToLower(r)
+			}
+			return '_'
+		}, v), "_"))
+	}
+	return strings.Join(parts, "_")
+}
+
+// tableIndexes returns the indexes declared by t.
+func tableIndexes(t Table) []Index {
+	i, ok := t.(Indexer)
+	if !ok {
+		return nil
+	}
+	var rst []Index
+	for _, v := range i.Indexes() {
+		if v.Table == "" {
+			v.Table = t.Name()
+		}
+		v.Name = indexName(v)
+		rst = append(rst, v)
+	}
+	return rst
+}
+
+// Indexes returns the indexes declared with the index and unique_index tags of
+// the fields, followed by the indexes declared by the model. Fields tagged with
+// an index of the same name make up a composite index.
+func (t *table) Indexes() []Index {
+	var rst []Index
+	position := make(map[string]int)
+	for _, f := range t.fields {
+		for _, key := range []string{specialTags.index, specialTags.uniqueIndex} {
+			name, ok := flagValue(f, key)
+			if !ok {
+				continue
+			}
+			if name == "" {
+				name = indexName(Index{Table: t.name, Columns: []string{f.ColumnName()}})
+			}
+			if n, ok := position[name]; ok {
+				rst[n].Columns = append(rst[n].Columns, f.ColumnName())
+				continue
+			}
+			position[name] = len(rst)
+			rst = append(rst, Index{
+				Name:    name,
+				Table:   t.name,
+				Columns: []string{f.ColumnName()},
+				Unique:  key == specialTags.uniqueIndex,
+			})
+		}
+	}
+	return append(rst, t.indexes...)
+}
+
+// CreateIndex creates idx if it does not exist.
+func (s *SQL) CreateIndex(idx Index) error {
+	if idx.Table == "" || len(idx.Columns) == 0 {
+		return errors.New("index needs a table and columns")
+	}
+	idx.Name = indexName(idx)
+	query, err := s.adopter.CreateIndex(idx)
+	if err != nil {
+		return err
+	}
+	_, err = s.exec(&statement{op: OpMigrate, table: idx.Table, query: query})
+	return err
+}
+
+// DropIndex drops idx if it exists.
+func (s *SQL) DropIndex(idx Index) error {
+	if idx.Name == "" && (idx.Table == "" || len(idx.Columns) == 0) {
+		return errors.New("index needs a name or a table and columns")
+	}
+	idx.Name = indexName(idx)
+	query, err := s.adopter.DropIndex(idx)
+	if err != nil {
+		return err
+	}
+	_, err = s.exec(&statement{op: OpMigrate, table: idx.Table, query: query})
+	return err
+}
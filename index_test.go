@@ -0,0 +1,71 @@
+package orange
+
+import (
+	"reflect"
+	"testing"
+)
+
+type indexedModel struct {
+	ID        int64
+	Email     string `sql:"unique_index"`
+	FirstName string `sql:"index:idx_full_name"`
+	LastName  string `sql:"index:idx_full_name"`
+	Tags      string
+}
+
+func (i *indexedModel) Indexes() []Index {
+	return []Index{
+		{Columns: []string{"lower(email)"}, Unique: true, Where: "email <> ''"},
+		{Name: "idx_tags", Columns: []string{"tags"}, Method: "gin"},
+	}
+}
+
+func TestTableIndexes(t *testing.T) {
+	tab, err := loadTable(&indexedModel{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	expect := []Index{
+		{Name: "idx_indexed_model_email", Table: "indexed_model", Columns: []string{"email"}, Unique: true},
+		{Name: "idx_full_name", Table: "indexed_model", Columns: []string{"first_name", "last_name"}},
+		{Name: "idx_indexed_model_lower_email", Table: "indexed_model", Columns: []string{"lower(email)"}, Unique: true, Where: "email <> ''"},
+		{Name: "idx_tags", Table: "indexed_model", Columns: []string{"tags"}, Method: "gin"},
+	}
+	indexes := tableIndexes(tab)
+	if !reflect.DeepEqual(indexes, expect) {
+		t.Errorf("expected %v got %v", expect, indexes)
+	}
+}
+
+func TestPostgres_CreateIndex(t *testing.T) {
+	p := &postgresql{}
+	sample := []struct {
+		idx    Index
+		expect string
+	}{
+		{
+			Index{Name: "idx_full_name", Table: "person", Columns: []string{"first_name", "last_name"}},
+			"CREATE INDEX IF NOT EXISTS idx_full_name ON person (first_name, last_name);",
+		},
+		{
+			Index{Name: "idx_email", Table: "person", Columns: []string{"lower(email)"}, Unique: true, Method: "btree", Where: "email <> ''"},
+			"CREATE UNIQUE INDEX IF NOT EXISTS idx_email ON person USING btree (lower(email)) WHERE email <> '';",
+		},
+	}
+	for _, v := range sample {
+		query, err := p.CreateIndex(v.idx)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if query != v.expect {
+			t.Errorf("expected %s got %s", v.expect, query)
+		}
+	}
+	drop, err := p.DropIndex(Index{Name: "idx_email"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if drop != "DROP INDEX IF EXISTS idx_email;" {
+		t.Errorf("expected DROP INDEX IF EXISTS idx_email; got %s", drop)
+	}
+}
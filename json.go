@@ -0,0 +1,121 @@
+package orange
+
+import (
+	"database/sql/driver"
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"strings"
+)
+
+// JSONColumn is implemented by field types which are stored as JSON, this is
+// the same as tagging the fields with json.
+//
+//	type Settings struct {
+//		Theme string `json:"theme"`
+//	}
+//
+//	func (Settings) JSONColumn() {}
+type JSONColumn interface {
+	JSONColumn()
+}
+
+var jsonColumnType = reflect.TypeOf((*JSONColumn)(nil)).Elem()
+
+// isJSON returns true if the value of f is stored as JSON.
+func isJSON(f Field) bool {
+	if hasFlag(f, specialTags.json) {
+		return true
+	}
+	typ := f.Type()
+	return typ.Implements(jsonColumnType) || reflect.PtrTo(typ).Implements(jsonColumnType)
+}
+
+// jsonValue writes a value as JSON.
+type jsonValue struct {
+	value interface{}
+}
+
+func (j jsonValue) Value() (driver.Value, error) {
+	b, err := json.Marshal(j.value)
+	if err != nil {
+		return nil, err
+	}
+	return string(b), nil
+}
+
+// jsonScanner scans JSON into dest, NULL leaves dest unchanged.
+type jsonScanner struct {
+	dest interface{}
+}
+
+func (j *jsonScanner) Scan(src interface{}) error {
+	switch v := src.(type) {
+	case nil:
+		return nil
+	case []byte:
+		return json.Unmarshal(v, j.dest)
+	case string:
+		return json.Unmarshal([]byte(v), j.dest)
+	}
+	return fmt.Errorf("can not scan %T as JSON", src)
+}
+
+// fieldValue returns the value written for the field f whose value is v.
+func fieldValue(f Field, v reflect.Value) interface{} {
+	if isJSON(f) {
+		return jsonValue{value: v.Interface()}
+	}
+	return v.Interface()
+}
+
+// andWhere adds cond to the where clause of s.
+func (s *SQL) andWhere(cond string) *SQL {
+	dup := s.CopyQuery()
+	if dup.clause.where == nil {
+		dup.clause.where = &clause{condition: cond}
+		return dup
+	}
+	dup.clause.where = &clause{
+		condition: dup.clause.where.condition + " AND" + cond,
+		args:      dup.clause.where.args,
+	}
+	return dup
+}
+
+// WhereJSONContains adds a condition matching rows whose JSON column contains
+// value, value is marshalled to JSON. It uses the postgres @> operator.
+//
+//	db.WhereJSONContains("settings", map[string]interface{}{"theme": "dark"})
+//	// WHERE settings @> '{"theme":"dark"}'
+func (s *SQL) WhereJSONContains(column string, value interface{}) *SQL {
+	v, err := s.quote(jsonValue{value: value})
+	if err != nil {
+		return s.queryError(fmt.Errorf("%s: %v", column, err))
+	}
+	return s.andWhere(fmt.Sprintf(" %s @> %s", column, v))
+}
+
+// WhereJSONField adds a condition matching rows whose JSON column has the field
+// at path with the text value. Nested fields are separated with dots. It uses
+// the postgres ->> and #>> operators.
+//
+//	db.WhereJSONField("settings", "theme", "dark")
+//	// WHERE settings->>'theme' = 'dark'
+func (s *SQL) WhereJSONField(column, path string, value interface{}) *SQL {
+	text, ok := value.(string)
+	if !ok {
+		text = fmt.Sprint(value)
+	}
+	v, err := s.quote(text)
+	if err != nil {
+		return s.queryError(fmt.Errorf("%s: %v", column, err))
+	}
+	keys := strings.Split(path, ".")
+	if len(keys) == 1 {
+		key, _ := s.quote(path)
+		return s.andWhere(fmt.Sprintf(" %s->>%s = %s", column, key, v))
+	}
+	key, _ := s.quote("{" + strings.Join(keys, ",") + "}")
+	return s.andWhere(fmt.Sprintf(" %s#>>%s = %s", column, key, v))
+}
@@ -0,0 +1,86 @@
+package orange
+
+import (
+	"reflect"
+	"testing"
+)
+
+type settings struct {
+	Theme string `json:"theme"`
+	Size  int    `json:"size"`
+}
+
+func (settings) JSONColumn() {}
+
+type profile struct {
+	ID       int64
+	Settings settings
+	Tags     []string          `sql:"json"`
+	Meta     map[string]string `sql:"json"`
+}
+
+func TestSQL_JSON(t *testing.T) {
+	db, err := Open("postgres", testDB.ps)
+	if err != nil {
+		t.Fatal(err)
+	}
+	query, err := db.adopter.Create(mustLoad(t, &profile{}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	create := "CREATE TABLE IF NOT EXISTS profile (id bigserial,settings jsonb,tags jsonb,meta jsonb,PRIMARY KEY (id));"
+	if query != create {
+		t.Errorf("expected %s got %s", create, query)
+	}
+	dry := db.DryRun()
+	err = dry.Create(&profile{ID: 1, Settings: settings{Theme: "dark"}, Tags: []string{"go", "sql"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	insert := `INSERT INTO profile (id, settings, tags) VALUES (1, '{"theme":"dark","size":0}', '["go","sql"]');`
+	if got := dry.Statements()[0].Query; got != insert {
+		t.Errorf("expected %s got %s", insert, got)
+	}
+
+	p := &profile{}
+	row := driverScanner{int64(1), []byte(`{"theme":"light","size":2}`), `["a"]`, nil}
+	err = db.scanStruct(row, []string{"id", "settings", "tags", "meta"}, p)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expect := &profile{ID: 1, Settings: settings{Theme: "light", Size: 2}, Tags: []string{"a"}}
+	if !reflect.DeepEqual(p, expect) {
+		t.Errorf("expected %v got %v", expect, p)
+	}
+}
+
+func TestSQL_WhereJSON(t *testing.T) {
+	db, err := Open("postgres", testDB.ps)
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = db.Register(&profile{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	sample := []struct {
+		query  *SQL
+		expect string
+	}{
+		{db.Copy().Select(&profile{}).WhereJSONContains("settings", map[string]string{"theme": "it's"}),
+			`SELECT * FROM profile WHERE settings @> '{"theme":"it''s"}';`},
+		{db.Copy().Select(&profile{}).WhereJSONField("settings", "size", 2),
+			"SELECT * FROM profile WHERE settings->>'size' = '2';"},
+		{db.Copy().Select(&profile{}).Where(&profile{ID: 1}).WhereJSONField("meta", "a.b", "c"),
+			"SELECT * FROM profile WHERE id=1 AND meta#>>'{a,b}' = 'c';"},
+	}
+	for _, v := range sample {
+		query, _, err := v.query.BuildQuery()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if query != v.expect {
+			t.Errorf("expected %s got %s", v.expect, query)
+		}
+	}
+}
@@ -0,0 +1,84 @@
+package orange
+
+// Models can implement the following interfaces to be notified about
+// operations performed on them. Hooks are called with the *SQL performing the
+// operation, so when it is bound to a transaction queries made by the hook run
+// inside the same transaction. Returning an error from a Before hook aborts the
+// operation, errors returned by After hooks are returned to the caller.
+
+// BeforeCreateHook is called before a model is inserted.
+type BeforeCreateHook interface {
+	BeforeCreate(*SQL) error
+}
+
+// AfterCreateHook is called after a model is inserted.
+type AfterCreateHook interface {
+	AfterCreate(*SQL) error
+}
+
+// BeforeUpdateHook is called before a model is updated.
+type BeforeUpdateHook interface {
+	BeforeUpdate(*SQL) error
+}
+
+// AfterUpdateHook is called after a model is updated.
+type AfterUpdateHook interface {
+	AfterUpdate(*SQL) error
+}
+
+// BeforeDeleteHook is called before a model is deleted.
+type BeforeDeleteHook interface {
+	BeforeDelete(*SQL) error
+}
+
+// AfterDeleteHook is called after a model is deleted.
+type AfterDeleteHook interface {
+	AfterDelete(*SQL) error
+}
+
+// AfterFindHook is called after a model has been loaded from the database.
+type AfterFindHook interface {
+	AfterFind(*SQL) error
+}
+
+// beforeHook calls the model hook which runs before op.
+func (s *SQL) beforeHook(model interface{}, op Operation) error {
+	switch op {
+	case OpCreate:
+		if h, ok := model.(BeforeCreateHook); ok {
+			return h.BeforeCreate(s)
+		}
+	case OpUpdate:
+		if h, ok := model.(BeforeUpdateHook); ok {
+			return h.BeforeUpdate(s)
+		}
+	case OpDelete:
+		if h, ok := model.(BeforeDeleteHook); ok {
+			return h.BeforeDelete(s)
+		}
+	}
+	return nil
+}
+
+// afterHook calls the model hook which runs after op.
+func (s *SQL) afterHook(model interface{}, op Operation) error {
+	switch op {
+	case OpCreate:
+		if h, ok := model.(AfterCreateHook); ok {
+			return h.AfterCreate(s)
+		}
+	case OpUpdate:
+		if h, ok := model.(AfterUpdateHook); ok {
+			return h.AfterUpdate(s)
+		}
+	case OpDelete:
+		if h, ok := model.(AfterDeleteHook); ok {
+			return h.AfterDelete(s)
+		}
+	case OpSelect:
+		if h, ok := model.(AfterFindHook); ok {
+			return h.AfterFind(s)
+		}
+	}
+	return nil
+}
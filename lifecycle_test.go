@@ -0,0 +1,121 @@
+package orange
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+var errHookAbort = errors.New("abort")
+
+type hookedModel struct {
+	ID   int64
+	Name string
+}
+
+func (h *hookedModel) BeforeCreate(s *SQL) error {
+	h.Name = strings.TrimSpace(h.Name)
+	if h.Name == "" {
+		return errHookAbort
+	}
+	return nil
+}
+
+func (h *hookedModel) BeforeDelete(s *SQL) error {
+	return errHookAbort
+}
+
+func TestSQL_ModelHooks(t *testing.T) {
+	db, err := Open("postgres", testDB.ps)
+	if err != nil {
+		t.Fatal(err)
+	}
+	_ = db.Register(&hookedModel{})
+	dry := db.DryRun()
+	err = dry.Create(&hookedModel{Name: "  "})
+	if err != errHookAbort {
+		t.Errorf("expected %v got %v", errHookAbort, err)
+	}
+	err = dry.Delete(&hookedModel{ID: 1})
+	if err != errHookAbort {
+		t.Errorf("expected %v got %v", errHookAbort, err)
+	}
+	m := &hookedModel{Name: " gernest "}
+	err = db.beforeHook(m, OpCreate)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if m.Name != "gernest" {
+		t.Errorf("expected gernest got %s", m.Name)
+	}
+}
+
+func TestSQL_Delete(t *testing.T) {
+	db, err := Open("postgres", testDB.ps)
+	if err != nil {
+		t.Fatal(err)
+	}
+	_ = db.Register(&golangster{})
+	query, args, err := db.delete(&golangster{ID: 2})
+	if err != nil {
+		t.Fatal(err)
+	}
+	expect := "DELETE FROM golangster WHERE id=$1"
+	if query != expect {
+		t.Errorf("expected %s got %s", expect, query)
+	}
+	if len(args) != 1 || args[0] != int64(2) {
+		t.Errorf("expected the key as argument got %v", args)
+	}
+	_, _, err = db.delete(&golangster{Name: "gernest"})
+	if err == nil {
+		t.Error("expected an error")
+	}
+}
+
+func TestSQL_Commit(t *testing.T) {
+	db, err := Open("postgres", testDB.ps)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err = db.Commit(); err != ErrNoTx {
+		t.Errorf("expected %v got %v", ErrNoTx, err)
+	}
+	if err = db.Rollback(); err != ErrNoTx {
+		t.Errorf("expected %v got %v", ErrNoTx, err)
+	}
+}
+
+type rolledBack struct {
+	ID   int64
+	Name string
+}
+
+func (r *rolledBack) AfterCreate(s *SQL) error {
+	return errHookAbort
+}
+
+func TestSQL_AfterHookRollback(t *testing.T) {
+	db, err := Open("postgres", testDB.ps)
+	if err != nil {
+		t.Fatal(err)
+	}
+	_ = db.Register(&rolledBack{})
+	err = db.Automigrate()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() { _ = db.DropTable(&rolledBack{}) }()
+	err = db.Create(&rolledBack{Name: "gernest"})
+	if err != errHookAbort {
+		t.Errorf("expected %v got %v", errHookAbort, err)
+	}
+	var count int
+	err = db.Select(&rolledBack{}).Count("*").Bind(&count)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if count != 0 {
+		t.Errorf("expected the insert to be rolled back got %d rows", count)
+	}
+}
@@ -0,0 +1,158 @@
+package orange
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"hash/fnv"
+	"time"
+)
+
+// ErrLockTimeout is returned when the migration lock can not be obtained before
+// the lock timeout.
+var ErrLockTimeout = errors.New("timed out waiting for the migration lock")
+
+// migrationLock is the name of the lock held while running migrations.
+const migrationLock = "orange_migrations"
+
+// defaultLockTimeout is how long migrations wait for the migration lock unless
+// it is changed with LockTimeout.
+const defaultLockTimeout = time.Minute
+
+// lockRetry is the interval between attempts to obtain a lock.
+var lockRetry = 250 * time.Millisecond
+
+// Locker is implemented by adopters which support session level locks. The
+// lock is held by the session of conn until it is unlocked or the connection is
+// closed, postgres uses advisory locks and mysql uses GET_LOCK.
+//
+// Adopters which are not lockers use a lock table instead.
+type Locker interface {
+
+	// TryLock tries to obtain the lock named name without waiting, ok is false
+	// when the lock is held by another session.
+	TryLock(ctx context.Context, conn *sql.Conn, name string) (ok bool, err error)
+
+	// Unlock releases the lock named name.
+	Unlock(ctx context.Context, conn *sql.Conn, name string) error
+}
+
+// LockTimeout sets how long Automigrate and migrations wait for the migration
+// lock before failing with ErrLockTimeout. The default is one minute.
+func (s *SQL) LockTimeout(d time.Duration) *SQL {
+	s.lockTimeout = d
+	return s
+}
+
+// locker returns the Locker of the adopter, falling back to the lock table.
+func (s *SQL) locker() Locker {
+	if l, ok := s.adopter.(Locker); ok {
+		return l
+	}
+	return &tableLocker{adopter: s.adopter}
+}
+
+// withLock calls fn while holding the lock named name. Concurrent processes
+// calling withLock with the same name run fn one at a time. Dry runs don't
+// change the database, fn is called with s without the lock.
+//
+// fn is called with a copy of s bound to the connection holding the lock, so
+// that its statements don't need another connection of the pool. This is what
+// makes locking work with db.SetMaxOpenConns(1).
+func (s *SQL) withLock(name string, fn func(*SQL) error) error {
+	if s.dryRun != nil {
+		return fn(s)
+	}
+	ctx := s.context()
+	conn, err := s.db.Conn(ctx)
+	if err != nil {
+		return err
+	}
+	defer func() { _ = conn.Close() }()
+	timeout := s.lockTimeout
+	if timeout <= 0 {
+		timeout = defaultLockTimeout
+	}
+	l := s.locker()
+	deadline := time.Now().Add(timeout)
+	for {
+		ok, err := l.TryLock(ctx, conn, name)
+		if err != nil {
+			return fmt.Errorf("obtaining lock %s: %v", name, err)
+		}
+		if ok {
+			break
+		}
+		if time.Now().After(deadline) {
+			return fmt.Errorf("%w %s after %s", ErrLockTimeout, name, timeout)
+		}
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(lockRetry):
+		}
+	}
+	defer func() { _ = l.Unlock(context.Background(), conn, name) }()
+	dup := s.Copy()
+	dup.session = conn
+	return fn(dup)
+}
+
+// lockKey returns the numeric key of the lock named name, for databases whose
+// locks are identified by numbers.
+func lockKey(name string) int64 {
+	h := fnv.New64a()
+	_, _ = h.Write([]byte(name))
+	return int64(h.Sum64())
+}
+
+// orangeLocks is the model for the lock table, a lock is held while its row
+// exists.
+type orangeLocks struct {
+	Name     string `sql:"name:lock_name;pk;type:varchar(255)"`
+	LockedAt time.Time
+}
+
+// tableLocker is a Locker which uses a table. Unlike session locks, a lock is
+// not released when the process holding it dies, the row has to be deleted
+// manually in that case.
+type tableLocker struct {
+	adopter Adopter
+}
+
+func (l *tableLocker) TryLock(ctx context.Context, conn *sql.Conn, name string) (bool, error) {
+	t, err := loadTable(&orangeLocks{})
+	if err != nil {
+		return false, err
+	}
+	query, err := l.adopter.Create(t)
+	if err != nil {
+		return false, err
+	}
+	_, err = conn.ExecContext(ctx, query)
+	if err != nil {
+		return false, err
+	}
+	query = fmt.Sprintf("INSERT INTO %s (lock_name, locked_at) VALUES (%s, %s);",
+		t.Name(), l.adopter.Quote(1), l.adopter.Quote(2))
+
+	_, err = conn.ExecContext(ctx, query, name, time.Now())
+	if err == nil {
+		return true, nil
+	}
+	// the insert fails with a unique violation when the row exists, which
+	// means the lock is held. Other failures are returned.
+	var held int
+	query = fmt.Sprintf("SELECT COUNT(*) FROM %s WHERE lock_name=%s;", t.Name(), l.adopter.Quote(1))
+	if cerr := conn.QueryRowContext(ctx, query, name).Scan(&held); cerr != nil || held == 0 {
+		return false, err
+	}
+	return false, nil
+}
+
+func (l *tableLocker) Unlock(ctx context.Context, conn *sql.Conn, name string) error {
+	query := fmt.Sprintf("DELETE FROM orange_locks WHERE lock_name=%s;", l.adopter.Quote(1))
+	_, err := conn.ExecContext(ctx, query, name)
+	return err
+}
@@ -0,0 +1,97 @@
+package orange
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestLockKey(t *testing.T) {
+	if lockKey(migrationLock) != lockKey(migrationLock) {
+		t.Error("expected the same key for the same name")
+	}
+	if lockKey(migrationLock) == lockKey("other") {
+		t.Error("expected different keys for different names")
+	}
+}
+
+func TestSQL_WithLock(t *testing.T) {
+	db, err := Open("postgres", testDB.ps)
+	if err != nil {
+		t.Fatal(err)
+	}
+	db.LockTimeout(100 * time.Millisecond)
+	called := false
+	err = db.withLock("orange_test_lock", func(*SQL) error {
+		called = true
+		return db.withLock("orange_test_lock", func(*SQL) error {
+			t.Error("expected the lock to be held")
+			return nil
+		})
+	})
+	if !errors.Is(err, ErrLockTimeout) {
+		t.Errorf("expected %v got %v", ErrLockTimeout, err)
+	}
+	if !called {
+		t.Error("expected the function to be called")
+	}
+}
+
+func TestSQL_WithLockSingleConn(t *testing.T) {
+	db, err := Open("postgres", testDB.ps)
+	if err != nil {
+		t.Fatal(err)
+	}
+	db.db.SetMaxOpenConns(1)
+	db.LockTimeout(time.Second)
+	err = db.withLock("orange_test_lock", func(locked *SQL) error {
+		_, err := locked.Exec("SELECT 1;")
+		if err != nil {
+			return err
+		}
+		tx, err := locked.Begin()
+		if err != nil {
+			return err
+		}
+		return tx.Rollback()
+	})
+	if err != nil {
+		t.Error(err)
+	}
+}
+
+func TestTableLocker(t *testing.T) {
+	db, err := Open("postgres", testDB.ps)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ctx := context.Background()
+	conn, err := db.db.Conn(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() { _ = conn.Close() }()
+	l := &tableLocker{adopter: db.adopter}
+	ok, err := l.TryLock(ctx, conn, "orange_test_lock")
+	if err != nil || !ok {
+		t.Fatalf("expected the lock got %v %v", ok, err)
+	}
+	defer func() { _ = db.DropTable(&orangeLocks{}) }()
+	ok, err = l.TryLock(ctx, conn, "orange_test_lock")
+	if err != nil || ok {
+		t.Errorf("expected the lock to be held got %v %v", ok, err)
+	}
+	err = l.Unlock(ctx, conn, "orange_test_lock")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// failures other than a held lock are errors
+	canceled, cancel := context.WithCancel(ctx)
+	cancel()
+	_, err = l.TryLock(canceled, conn, "orange_test_lock")
+	if err == nil {
+		t.Error("expected an error for a canceled context")
+	}
+}
@@ -0,0 +1,52 @@
+package orange
+
+import "expvar"
+
+// ExpvarHook is a Hook that publishes query metrics with the expvar package.
+// Metrics are keyed by table and operation e.g golangster.select, statements
+// which are not tied to a table are keyed by the operation alone.
+type ExpvarHook struct {
+	// Count is the number of executed statements.
+	Count *expvar.Map
+
+	// Errors is the number of statements that returned an error.
+	Errors *expvar.Map
+
+	// Latency is the total time in nanoseconds spent executing statements.
+	Latency *expvar.Map
+}
+
+// NewExpvarHook returns a new ExpvarHook whose metrics are published under
+// name. Like expvar.Publish it panics if name is already in use.
+func NewExpvarHook(name string) *ExpvarHook {
+	h := &ExpvarHook{
+		Count:   new(expvar.Map).Init(),
+		Errors:  new(expvar.Map).Init(),
+		Latency: new(expvar.Map).Init(),
+	}
+	m := expvar.NewMap(name)
+	m.Set("count", h.Count)
+	m.Set("errors", h.Errors)
+	m.Set("latency_ns", h.Latency)
+	return h
+}
+
+// BeforeQuery implements Hook.
+func (h *ExpvarHook) BeforeQuery(e *QueryEvent) {}
+
+// AfterQuery implements Hook.
+func (h *ExpvarHook) AfterQuery(e *QueryEvent) {
+	key := metricKey(e)
+	h.Count.Add(key, 1)
+	if e.Err != nil {
+		h.Errors.Add(key, 1)
+	}
+	h.Latency.Add(key, int64(e.Duration))
+}
+
+func metricKey(e *QueryEvent) string {
+	if e.Table == "" {
+		return string(e.Operation)
+	}
+	return e.Table + "." + string(e.Operation)
+}
@@ -0,0 +1,39 @@
+package orange
+
+import (
+	"errors"
+	"expvar"
+	"testing"
+	"time"
+)
+
+func TestExpvarHook(t *testing.T) {
+	h := NewExpvarHook("orange_test_metrics")
+	h.AfterQuery(&QueryEvent{Operation: OpSelect, Table: "golangster", Duration: time.Second})
+	h.AfterQuery(&QueryEvent{Operation: OpSelect, Table: "golangster", Err: errors.New("fail")})
+	h.AfterQuery(&QueryEvent{Operation: OpExec})
+
+	sample := []struct {
+		m      *expvar.Map
+		key    string
+		expect string
+	}{
+		{h.Count, "golangster.select", "2"},
+		{h.Errors, "golangster.select", "1"},
+		{h.Latency, "golangster.select", "1000000000"},
+		{h.Count, "exec", "1"},
+	}
+	for _, v := range sample {
+		got := v.m.Get(v.key)
+		if got == nil {
+			t.Errorf("expected %s to be set", v.key)
+			continue
+		}
+		if got.String() != v.expect {
+			t.Errorf("expected %s got %s", v.expect, got)
+		}
+	}
+	if expvar.Get("orange_test_metrics") == nil {
+		t.Error("expected metrics to be published")
+	}
+}
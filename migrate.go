@@ -0,0 +1,450 @@
+package orange
+
+import (
+	"fmt"
+	"io/fs"
+	"path"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Migration is a versioned change to the database schema. Migrations are
+// applied in the order of their versions, each inside its own transaction.
+type Migration struct {
+	Version int64
+	Name    string
+
+	// Up applies the migration.
+	Up func(*SQL) error
+
+	// Down reverts the migration, a migration without Down can not be rolled
+	// back.
+	Down func(*SQL) error
+}
+
+// MigrationStatus is the state of a migration in the database.
+type MigrationStatus struct {
+	Version   int64
+	Name      string
+	Applied   bool
+	AppliedAt time.Time
+
+	// Missing is true when the migration is applied in the database but it is
+	// not registered.
+	Missing bool
+}
+
+// schemaMigrations is the model for the table which keeps track of the applied
+// migrations.
+type schemaMigrations struct {
+	Version   int64 `sql:"pk;type:bigint"`
+	Name      string
+	AppliedAt time.Time
+}
+
+// Migrator manages the migrations registered with a *SQL.
+//
+//	m := db.Migrations()
+//	err := m.Load(os.DirFS("."), "migrations")
+//	...
+//	err = m.Migrate()
+//
+// Migrate, MigrateTo and Rollback hold the migration lock, so that processes
+// starting at the same time don't apply the same migrations. See LockTimeout.
+type Migrator struct {
+	db *SQL
+}
+
+// Migrations returns the migrator of s. Migrations are shared by all copies of
+// s, and are executed with s.
+func (s *SQL) Migrations() *Migrator {
+	return &Migrator{db: s}
+}
+
+// migrations is the collection of registered migrations.
+type migrations struct {
+	mu   sync.RWMutex
+	list []Migration
+}
+
+// sorted returns the registered migrations ordered by version.
+func (m *migrations) sorted() []Migration {
+	m.mu.RLock()
+	rst := make([]Migration, len(m.list))
+	copy(rst, m.list)
+	m.mu.RUnlock()
+	sort.Sort(byVersion(rst))
+	return rst
+}
+
+type byVersion []Migration
+
+func (b byVersion) Len() int           { return len(b) }
+func (b byVersion) Less(i, j int) bool { return b[i].Version < b[j].Version }
+func (b byVersion) Swap(i, j int)      { b[i], b[j] = b[j], b[i] }
+
+// Add registers migrations. Versions must be unique.
+func (m *Migrator) Add(migrations ...Migration) error {
+	r := m.db.migrations
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for _, v := range migrations {
+		if v.Up == nil {
+			return fmt.Errorf("migration %d has no up step", v.Version)
+		}
+		for _, e := range r.list {
+			if e.Version == v.Version {
+				return fmt.Errorf("migration %d is already registered", v.Version)
+			}
+		}
+		r.list = append(r.list, v)
+	}
+	return nil
+}
+
+// Load registers the sql migrations found in the directory dir of
+// fsys. Files are named after the version and the name of the migration, the
+// up step ends with .up.sql and the down step with .down.sql
+//
+//	0001_create_users.up.sql
+//	0001_create_users.down.sql
+//
+// Other files are ignored.
+func (m *Migrator) Load(fsys fs.FS, dir string) error {
+	entries, err := fs.ReadDir(fsys, dir)
+	if err != nil {
+		return err
+	}
+	found := make(map[int64]*Migration)
+	var versions []int64
+	for _, e := range entries {
+		if e.IsDir() {
+			continue
+		}
+		version, name, up, ok := parseMigrationFile(e.Name())
+		if !ok {
+			continue
+		}
+		data, err := fs.ReadFile(fsys, path.Join(dir, e.Name()))
+		if err != nil {
+			return err
+		}
+		mig, ok := found[version]
+		if !ok {
+			mig = &Migration{Version: version, Name: name}
+			found[version] = mig
+			versions = append(versions, version)
+		}
+		step := sqlStep(string(data))
+		if up {
+			mig.Up = step
+		} else {
+			mig.Down = step
+		}
+	}
+	for _, v := range versions {
+		if err := m.Add(*found[v]); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// parseMigrationFile returns the version and name of a migration file, up is
+// true for the up step. ok is false when name is not a migration file.
+func parseMigrationFile(name string) (version int64, migration string, up, ok bool) {
+	switch {
+	case strings.HasSuffix(name, ".up.sql"):
+		up = true
+		name = strings.TrimSuffix(name, ".up.sql")
+	case strings.HasSuffix(name, ".down.sql"):
+		name = strings.TrimSuffix(name, ".down.sql")
+	default:
+		return 0, "", false, false
+	}
+	n := strings.Index(name, "_")
+	if n < 0 {
+		n = len(name)
+	}
+	version, err := strconv.ParseInt(name[:n], 10, 64)
+	if err != nil {
+		return 0, "", false, false
+	}
+	if n < len(name) {
+		migration = name[n+1:]
+	}
+	return version, migration, up, true
+}
+
+// sqlStep returns a migration step which executes query.
+func sqlStep(query string) func(*SQL) error {
+	return func(s *SQL) error {
+		_, err := s.exec(&statement{op: OpMigrate, query: query})
+		return err
+	}
+}
+
+// Migrate applies all pending migrations, these are the registered migrations
+// which are not applied. Applied migrations which are not registered, for
+// instance those of a newer release sharing the database, are left alone and
+// reported by Status.
+func (m *Migrator) Migrate() error {
+	return m.migrate(func(all []Migration, applied map[int64]MigrationStatus) ([]migrationStep, error) {
+		return planPending(all, applied), nil
+	})
+}
+
+// MigrateTo applies or rolls back migrations until version is the latest
+// applied migration. Using version 0 rolls back all migrations.
+func (m *Migrator) MigrateTo(version int64) error {
+	return m.migrate(func(all []Migration, applied map[int64]MigrationStatus) ([]migrationStep, error) {
+		return planMigrations(all, applied, version)
+	})
+}
+
+// Rollback rolls back the latest steps applied migrations.
+func (m *Migrator) Rollback(steps int) error {
+	return m.migrate(func(all []Migration, applied map[int64]MigrationStatus) ([]migrationStep, error) {
+		return planRollback(all, applied, steps)
+	})
+}
+
+// Status returns the state of all registered and applied migrations ordered
+// by version. It does not change the database, when the migrations table does
+// not exist every registered migration is pending.
+func (m *Migrator) Status() ([]MigrationStatus, error) {
+	applied := make(map[int64]MigrationStatus)
+	exists, err := m.db.migrationTableExists()
+	if err != nil {
+		return nil, err
+	}
+	if exists {
+		applied, err = m.db.appliedMigrations()
+		if err != nil {
+			return nil, err
+		}
+	}
+	var rst []MigrationStatus
+	for _, v := range m.db.migrations.sorted() {
+		st, ok := applied[v.Version]
+		if !ok {
+			st = MigrationStatus{Version: v.Version}
+		}
+		st.Name = v.Name
+		delete(applied, v.Version)
+		rst = append(rst, st)
+	}
+	for _, v := range applied {
+		v.Missing = true
+		rst = append(rst, v)
+	}
+	sort.Sort(statusByVersion(rst))
+	return rst, nil
+}
+
+type statusByVersion []MigrationStatus
+
+func (b statusByVersion) Len() int           { return len(b) }
+func (b statusByVersion) Less(i, j int) bool { return b[i].Version < b[j].Version }
+func (b statusByVersion) Swap(i, j int)      { b[i], b[j] = b[j], b[i] }
+
+// migrationStep is a single migration to apply or roll back.
+type migrationStep struct {
+	migration Migration
+	up        bool
+}
+
+// planPending returns the steps applying the registered migrations which are
+// not applied.
+func planPending(all []Migration, applied map[int64]MigrationStatus) []migrationStep {
+	var steps []migrationStep
+	for _, v := range all {
+		if _, ok := applied[v.Version]; !ok {
+			steps = append(steps, migrationStep{migration: v, up: true})
+		}
+	}
+	return steps
+}
+
+// planMigrations returns the steps needed for target to be the latest applied
+// migration.
+func planMigrations(all []Migration, applied map[int64]MigrationStatus, target int64) ([]migrationStep, error) {
+	var steps []migrationStep
+	for _, v := range all {
+		if _, ok := applied[v.Version]; !ok && v.Version <= target {
+			steps = append(steps, migrationStep{migration: v, up: true})
+		}
+	}
+	var down []int64
+	for v := range applied {
+		if v > target {
+			down = append(down, v)
+		}
+	}
+	rollback, err := rollbackSteps(all, down)
+	if err != nil {
+		return nil, err
+	}
+	return append(steps, rollback...), nil
+}
+
+// planRollback returns the steps for rolling back the latest n applied
+// migrations.
+func planRollback(all []Migration, applied map[int64]MigrationStatus, n int) ([]migrationStep, error) {
+	var versions []int64
+	for v := range applied {
+		versions = append(versions, v)
+	}
+	sort.Sort(sort.Reverse(int64s(versions)))
+	if n < len(versions) {
+		versions = versions[:n]
+	}
+	return rollbackSteps(all, versions)
+}
+
+// rollbackSteps returns the steps for rolling back versions, latest first.
+func rollbackSteps(all []Migration, versions []int64) ([]migrationStep, error) {
+	sort.Sort(sort.Reverse(int64s(versions)))
+	var steps []migrationStep
+	for _, v := range versions {
+		var m *Migration
+		for k := range all {
+			if all[k].Version == v {
+				m = &all[k]
+			}
+		}
+		switch {
+		case m == nil:
+			return nil, fmt.Errorf("migration %d is applied but not registered", v)
+		case m.Down == nil:
+			return nil, fmt.Errorf("migration %d can not be rolled back", v)
+		}
+		steps = append(steps, migrationStep{migration: *m})
+	}
+	return steps, nil
+}
+
+type int64s []int64
+
+func (b int64s) Len() int           { return len(b) }
+func (b int64s) Less(i, j int) bool { return b[i] < b[j] }
+func (b int64s) Swap(i, j int)      { b[i], b[j] = b[j], b[i] }
+
+// migrate executes the steps returned by plan while holding the migration lock.
+func (m *Migrator) migrate(plan func([]Migration, map[int64]MigrationStatus) ([]migrationStep, error)) error {
+	return m.db.withLock(migrationLock, func(locked *SQL) error {
+		return locked.Migrations().run(plan)
+	})
+}
+
+func (m *Migrator) run(plan func([]Migration, map[int64]MigrationStatus) ([]migrationStep, error)) error {
+	err := m.db.createMigrationTable()
+	if err != nil {
+		return err
+	}
+	applied, err := m.db.appliedMigrations()
+	if err != nil {
+		return err
+	}
+	steps, err := plan(m.db.migrations.sorted(), applied)
+	if err != nil {
+		return err
+	}
+	for _, v := range steps {
+		err = m.db.inTx(func(tx *SQL) error {
+			return tx.runMigration(v)
+		})
+		if err != nil {
+			return fmt.Errorf("migration %d %s: %v", v.migration.Version, v.migration.Name, err)
+		}
+	}
+	return nil
+}
+
+// runMigration executes step and records it in the migrations table.
+func (s *SQL) runMigration(step migrationStep) error {
+	m := step.migration
+	if !step.up {
+		err := m.Down(s)
+		if err != nil {
+			return err
+		}
+		query := "DELETE FROM schema_migrations WHERE version=" + s.adopter.Quote(1) + ";"
+		_, err = s.exec(&statement{op: OpMigrate, table: "schema_migrations", query: query, args: []interface{}{m.Version}})
+		return err
+	}
+	err := m.Up(s)
+	if err != nil {
+		return err
+	}
+	query := fmt.Sprintf("INSERT INTO schema_migrations (version, name, applied_at) VALUES (%s, %s, %s);",
+		s.adopter.Quote(1), s.adopter.Quote(2), s.adopter.Quote(3))
+	_, err = s.exec(&statement{
+		op:    OpMigrate,
+		table: "schema_migrations",
+		query: query,
+		args:  []interface{}{m.Version, m.Name, time.Now()},
+	})
+	return err
+}
+
+// createMigrationTable creates the table that keeps track of applied
+// migrations.
+func (s *SQL) createMigrationTable() error {
+	t, err := loadTable(&schemaMigrations{})
+	if err != nil {
+		return err
+	}
+	query, err := s.adopter.Create(t)
+	if err != nil {
+		return err
+	}
+	_, err = s.exec(&statement{op: OpMigrate, table: t.Name(), query: query})
+	return err
+}
+
+// migrationTableExists returns true if the migrations table exists in the
+// database.
+func (s *SQL) migrationTableExists() (bool, error) {
+	schema, err := s.Inspect()
+	if err != nil {
+		return false, err
+	}
+	return schema.Table("schema_migrations") != nil, nil
+}
+
+// appliedMigrations returns the migrations recorded in the migrations table.
+func (s *SQL) appliedMigrations() (map[int64]MigrationStatus, error) {
+	if s.dryRun != nil {
+		// the migrations table is not created by dry runs.
+		exists, err := s.migrationTableExists()
+		if err != nil {
+			return nil, err
+		}
+		if !exists {
+			return make(map[int64]MigrationStatus), nil
+		}
+	}
+	rows, err := s.query(&statement{
+		op:    OpSelect,
+		table: "schema_migrations",
+		query: "SELECT version, name, applied_at FROM schema_migrations;",
+	})
+	if err != nil {
+		return nil, err
+	}
+	defer func() { _ = rows.Close() }()
+	rst := make(map[int64]MigrationStatus)
+	for rows.Next() {
+		st := MigrationStatus{Applied: true}
+		err = rows.Scan(&st.Version, &st.Name, &st.AppliedAt)
+		if err != nil {
+			return nil, err
+		}
+		rst[st.Version] = st
+	}
+	return rst, rows.Err()
+}
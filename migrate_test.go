@@ -0,0 +1,185 @@
+package orange
+
+import (
+	"reflect"
+	"testing"
+	"testing/fstest"
+)
+
+func TestParseMigrationFile(t *testing.T) {
+	sample := []struct {
+		file    string
+		version int64
+		name    string
+		up, ok  bool
+	}{
+		{"0001_create_users.up.sql", 1, "create_users", true, true},
+		{"0001_create_users.down.sql", 1, "create_users", false, true},
+		{"20240102.up.sql", 20240102, "", true, true},
+		{"create_users.up.sql", 0, "", false, false},
+		{"0001_create_users.sql", 0, "", false, false},
+		{"README.md", 0, "", false, false},
+	}
+	for _, v := range sample {
+		version, name, up, ok := parseMigrationFile(v.file)
+		if version != v.version || name != v.name || up != v.up || ok != v.ok {
+			t.Errorf("%s: expected %d %q %v %v got %d %q %v %v", v.file,
+				v.version, v.name, v.up, v.ok, version, name, up, ok)
+		}
+	}
+}
+
+func TestMigrator_Load(t *testing.T) {
+	db, err := Open("postgres", testDB.ps)
+	if err != nil {
+		t.Fatal(err)
+	}
+	fsys := fstest.MapFS{
+		"migrations/0002_add_email.up.sql":      {Data: []byte("ALTER TABLE users ADD COLUMN email text;")},
+		"migrations/0001_create_users.up.sql":   {Data: []byte("CREATE TABLE users (id serial);")},
+		"migrations/0001_create_users.down.sql": {Data: []byte("DROP TABLE users;")},
+		"migrations/notes.txt":                  {Data: []byte("ignored")},
+	}
+	m := db.Migrations()
+	err = m.Load(fsys, "migrations")
+	if err != nil {
+		t.Fatal(err)
+	}
+	all := db.migrations.sorted()
+	if len(all) != 2 {
+		t.Fatalf("expected 2 got %d", len(all))
+	}
+	if all[0].Version != 1 || all[0].Name != "create_users" || all[0].Down == nil {
+		t.Errorf("expected reversible migration 1 create_users got %d %s", all[0].Version, all[0].Name)
+	}
+	if all[1].Version != 2 || all[1].Name != "add_email" || all[1].Down != nil {
+		t.Errorf("expected irreversible migration 2 add_email got %d %s", all[1].Version, all[1].Name)
+	}
+	err = m.Load(fsys, "migrations")
+	if err == nil {
+		t.Error("expected an error for duplicate versions")
+	}
+	err = m.Add(Migration{Version: 3})
+	if err == nil {
+		t.Error("expected an error for a migration without up step")
+	}
+}
+
+func TestPlanMigrations(t *testing.T) {
+	step := func(*SQL) error { return nil }
+	all := []Migration{
+		{Version: 1, Up: step, Down: step},
+		{Version: 2, Up: step, Down: step},
+		{Version: 3, Up: step},
+		{Version: 4, Up: step, Down: step},
+	}
+	applied := map[int64]MigrationStatus{1: {Version: 1}, 2: {Version: 2}}
+	versions := func(steps []migrationStep) []int64 {
+		var rst []int64
+		for _, v := range steps {
+			n := v.migration.Version
+			if !v.up {
+				n = -n
+			}
+			rst = append(rst, n)
+		}
+		return rst
+	}
+	sample := []struct {
+		target int64
+		expect []int64
+	}{
+		{4, []int64{3, 4}},
+		{3, []int64{3}},
+		{2, nil},
+		{1, []int64{-2}},
+		{0, []int64{-2, -1}},
+	}
+	for _, v := range sample {
+		steps, err := planMigrations(all, applied, v.target)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got := versions(steps); !reflect.DeepEqual(got, v.expect) {
+			t.Errorf("target %d: expected %v got %v", v.target, v.expect, got)
+		}
+	}
+
+	steps, err := planRollback(all, applied, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := versions(steps); !reflect.DeepEqual(got, []int64{-2}) {
+		t.Errorf("expected [-2] got %v", got)
+	}
+	steps, err = planRollback(all, applied, 5)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := versions(steps); !reflect.DeepEqual(got, []int64{-2, -1}) {
+		t.Errorf("expected [-2 -1] got %v", got)
+	}
+
+	applied[3] = MigrationStatus{Version: 3}
+	_, err = planRollback(all, applied, 1)
+	if err == nil {
+		t.Error("expected an error rolling back an irreversible migration")
+	}
+	applied[9] = MigrationStatus{Version: 9}
+	_, err = planMigrations(all, applied, 4)
+	if err == nil {
+		t.Error("expected an error rolling back an unregistered migration")
+	}
+}
+
+func TestPlanPending(t *testing.T) {
+	step := func(*SQL) error { return nil }
+	all := []Migration{
+		{Version: 1, Up: step},
+		{Version: 2, Up: step},
+		{Version: 4, Up: step},
+	}
+	sample := []struct {
+		all     []Migration
+		applied map[int64]MigrationStatus
+		expect  []int64
+	}{
+		{all, map[int64]MigrationStatus{}, []int64{1, 2, 4}},
+		{all, map[int64]MigrationStatus{1: {Version: 1}, 3: {Version: 3}}, []int64{2, 4}},
+
+		// the database is ahead of the registered migrations
+		{all, map[int64]MigrationStatus{1: {Version: 1}, 2: {Version: 2}, 9: {Version: 9}}, []int64{4}},
+		{all, map[int64]MigrationStatus{1: {Version: 1}, 2: {Version: 2}, 4: {Version: 4}, 9: {Version: 9}}, nil},
+		{nil, map[int64]MigrationStatus{9: {Version: 9}}, nil},
+	}
+	for _, v := range sample {
+		var got []int64
+		for _, s := range planPending(v.all, v.applied) {
+			if !s.up {
+				t.Errorf("expected only up steps got down %d", s.migration.Version)
+			}
+			got = append(got, s.migration.Version)
+		}
+		if !reflect.DeepEqual(got, v.expect) {
+			t.Errorf("expected %v got %v", v.expect, got)
+		}
+	}
+}
+
+func TestMigrator_StatusReadOnly(t *testing.T) {
+	db, err := Open("postgres", "host=/nonexistent/orange dbname=orange_test sslmode=disable")
+	if err != nil {
+		t.Fatal(err)
+	}
+	h := &recordHook{}
+	db.AddHook(h)
+	_, err = db.Migrations().Status()
+	if err == nil {
+		t.Error("expected the connection error")
+	}
+	for _, v := range h.before {
+		if v.Operation != OpSelect {
+			t.Errorf("expected only queries got %s %s", v.Operation, v.Query)
+		}
+	}
+}
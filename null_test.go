@@ -0,0 +1,116 @@
+package orange
+
+import (
+	"database/sql"
+	"testing"
+	"time"
+)
+
+type nullableModel struct {
+	ID       int64
+	Nick     *string `sql:"size:32"`
+	Age      *int
+	Email    sql.NullString
+	Score    sql.NullInt64 `sql:"default:0"`
+	Birthday *time.Time
+}
+
+func TestSQL_Quote(t *testing.T) {
+	db, err := Open("postgres", testDB.ps)
+	if err != nil {
+		t.Fatal(err)
+	}
+	name := "o'reilly"
+	var nilName *string
+	day := time.Date(2016, 1, 2, 3, 4, 5, 0, time.UTC)
+	sample := []struct {
+		value  interface{}
+		expect string
+	}{
+		{nil, "NULL"},
+		{"gernest", "'gernest'"},
+		{name, "'o''reilly'"},
+		{&name, "'o''reilly'"},
+		{nilName, "NULL"},
+		{status("active"), "'active'"},
+		{int64(10), "10"},
+		{true, "true"},
+		{[]byte{1, 171}, `'\x01ab'`},
+		{day, "'2016-01-02T03:04:05Z'"},
+		{sql.NullString{}, "NULL"},
+		{sql.NullString{Valid: true}, "''"},
+		{sql.NullInt64{Int64: 0, Valid: true}, "0"},
+	}
+	for _, v := range sample {
+		got, err := db.quote(v.value)
+		if err != nil {
+			t.Errorf("%v: %v", v.value, err)
+			continue
+		}
+		if got != v.expect {
+			t.Errorf("expected %s got %s", v.expect, got)
+		}
+	}
+	_, err = db.quote(money{})
+	if err == nil {
+		t.Error("expected an error for a value without a sql representation")
+	}
+}
+
+func TestSQL_Nullable(t *testing.T) {
+	db, err := Open("postgres", testDB.ps)
+	if err != nil {
+		t.Fatal(err)
+	}
+	zero := 0
+	dry := db.DryRun()
+	err = dry.Create(&nullableModel{ID: 1, Age: &zero})
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = dry.Update(&nullableModel{ID: 1, Email: sql.NullString{Valid: true}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = dry.UpdateColumns(&nullableModel{ID: 1, Age: &zero}, "nick", "score")
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = dry.UpdateColumns(&nullableModel{ID: 1}, "missing")
+	if err == nil {
+		t.Error("expected an error for an unknown column")
+	}
+	expect := []string{
+		"INSERT INTO nullable_model (id, nick, age, email, birthday) VALUES (1, NULL, 0, NULL, NULL);",
+		// partial updates leave the other columns alone
+		"UPDATE nullable_model SET email ='' WHERE id=$1",
+		"UPDATE nullable_model SET nick =NULL,age =0,score =NULL WHERE id=$1",
+	}
+	got := dry.Statements()
+	if len(got) != len(expect) {
+		t.Fatalf("expected %d statements got %d", len(expect), len(got))
+	}
+	for k, v := range expect {
+		if got[k].Query != v {
+			t.Errorf("expected %s got %s", v, got[k].Query)
+		}
+	}
+
+	query, err := db.adopter.Create(mustLoad(t, &nullableModel{}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	create := "CREATE TABLE IF NOT EXISTS nullable_model (id bigserial,nick varchar(32),age integer," +
+		"email text,score bigint DEFAULT 0,birthday timestamp with time zone,PRIMARY KEY (id));"
+	if query != create {
+		t.Errorf("expected %s got %s", create, query)
+	}
+}
+
+func mustLoad(t *testing.T, model interface{}) Table {
+	tab, err := loadTable(model)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return tab
+}
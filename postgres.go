@@ -2,13 +2,83 @@ package orange
 
 import (
 	"bytes"
+	"context"
+	"database/sql"
 	"fmt"
+	"math/big"
+	"net"
 	"reflect"
 	"strings"
+	"sync"
 	"time"
 )
 
-type postgresql struct{}
+type postgresql struct {
+	once  sync.Once
+	types *typeRegistry
+}
+
+// postgresTypes are the default column types of the Go types.
+var postgresTypes = map[reflect.Type]string{
+	reflect.TypeOf(""):          "text",
+	reflect.TypeOf(false):       "boolean",
+	reflect.TypeOf(int(0)):      "integer",
+	reflect.TypeOf(int8(0)):     "smallint",
+	reflect.TypeOf(int16(0)):    "smallint",
+	reflect.TypeOf(int32(0)):    "integer",
+	reflect.TypeOf(int64(0)):    "bigint",
+	reflect.TypeOf(uint(0)):     "numeric(20,0)",
+	reflect.TypeOf(uint8(0)):    "smallint",
+	reflect.TypeOf(uint16(0)):   "integer",
+	reflect.TypeOf(uint32(0)):   "bigint",
+	reflect.TypeOf(uint64(0)):   "numeric(20,0)",
+	reflect.TypeOf(float32(0)):  "real",
+	reflect.TypeOf(float64(0)):  "double precision",
+	bytesType:                   "bytea",
+	reflect.TypeOf(time.Time{}): "timestamp with time zone",
+
+	reflect.TypeOf(sql.NullString{}):  "text",
+	reflect.TypeOf(sql.NullBool{}):    "boolean",
+	reflect.TypeOf(sql.NullByte{}):    "smallint",
+	reflect.TypeOf(sql.NullInt16{}):   "smallint",
+	reflect.TypeOf(sql.NullInt32{}):   "integer",
+	reflect.TypeOf(sql.NullInt64{}):   "bigint",
+	reflect.TypeOf(sql.NullFloat64{}): "double precision",
+	reflect.TypeOf(sql.NullTime{}):    "timestamp with time zone",
+
+	reflect.TypeOf([]string(nil)):    "text[]",
+	reflect.TypeOf([]bool(nil)):      "boolean[]",
+	reflect.TypeOf([]int(nil)):       "integer[]",
+	reflect.TypeOf([]int16(nil)):     "smallint[]",
+	reflect.TypeOf([]int32(nil)):     "integer[]",
+	reflect.TypeOf([]int64(nil)):     "bigint[]",
+	reflect.TypeOf([]float32(nil)):   "real[]",
+	reflect.TypeOf([]float64(nil)):   "double precision[]",
+	reflect.TypeOf(UUID{}):           "uuid",
+	reflect.TypeOf([16]byte{}):       "uuid",
+	reflect.TypeOf(net.IP(nil)):      "inet",
+	reflect.TypeOf(big.Rat{}):        "numeric",
+	reflect.TypeOf(time.Duration(0)): "interval",
+}
+
+// serialTypes are the auto incrementing column types of the integer types.
+var serialTypes = map[string]string{
+	"smallint": "smallserial",
+	"integer":  "serial",
+	"bigint":   "bigserial",
+}
+
+func (p *postgresql) registry() *typeRegistry {
+	p.once.Do(func() {
+		p.types = newTypeRegistry(postgresTypes)
+	})
+	return p.types
+}
+
+// RegisterType sets the column type of typ.
+func (p *postgresql) RegisterType(typ reflect.Type, column string) {
+	p.registry().register(typ, column)
+}
 
 // Create returns sql query for creating table t if it does not exist
 func (p *postgresql) Create(t Table) (string, error) {
@@ -18,63 +88,469 @@ func (p *postgresql) Create(t Table) (string, error) {
 	if err != nil {
 		return "", err
 	}
-	size := len(fields)
 	for k, v := range fields {
 		column, err := p.Field(v)
 		if err != nil {
-			return "", nil
+			return "", err
 		}
-		if k == size-1 {
-			_, _ = buf.WriteString(column)
-			break
+		if k > 0 {
+			_, _ = buf.WriteString(",")
 		}
-		_, _ = buf.WriteString(column + ",")
+		_, _ = buf.WriteString(column)
+	}
+	if keys := primaryKeys(t); keys != nil {
+		var cols []string
+		for _, v := range keys {
+			cols = append(cols, v.ColumnName())
+		}
+		_, _ = buf.WriteString(",PRIMARY KEY (" + strings.Join(cols, ", ") + ")")
+	}
+	for _, v := range foreignKeys(t) {
+		_, _ = buf.WriteString(",FOREIGN KEY (" + v.Column + ")" + references(v))
 	}
 	_, _ = buf.WriteString(");")
 	return buf.String(), nil
 }
 
+// references returns the REFERENCES clause for the foreign key fk.
+func references(fk ForeignKey) string {
+	rst := " REFERENCES " + fk.RefTable + " (" + fk.RefColumn + ")"
+	if fk.OnDelete != "" {
+		rst += " ON DELETE " + fk.OnDelete
+	}
+	if fk.OnUpdate != "" {
+		rst += " ON UPDATE " + fk.OnUpdate
+	}
+	return rst
+}
+
 // Drop returns sql query for dropping table t.
 func (p *postgresql) Drop(t Table) (string, error) {
 	query := "DROP TABLE IF EXISTS " + t.Name()
 	return query, nil
 }
 
+// DropCascade returns sql query for dropping table and the objects that depend
+// on it e.g foreign keys of other tables.
+func (p *postgresql) DropCascade(table string) (string, error) {
+	return "DROP TABLE IF EXISTS " + table + " CASCADE;", nil
+}
+
+// RenameTable returns sql query for renaming table from to to.
+func (p *postgresql) RenameTable(from, to string) (string, error) {
+	return "ALTER TABLE " + from + " RENAME TO " + to + ";", nil
+}
+
+// RenameColumn returns sql query for renaming column from of table to to.
+func (p *postgresql) RenameColumn(table, from, to string) (string, error) {
+	return "ALTER TABLE " + table + " RENAME COLUMN " + from + " TO " + to + ";", nil
+}
+
+// DropColumn returns sql query for dropping column of table if it exists.
+func (p *postgresql) DropColumn(table, column string) (string, error) {
+	return "ALTER TABLE " + table + " DROP COLUMN IF EXISTS " + column + ";", nil
+}
+
+// AlterColumnType returns sql query for changing the type of the column of f.
+// Existing values are cast to the new type.
+func (p *postgresql) AlterColumnType(t Table, f Field) (string, error) {
+	typ, err := p.ColumnType(f)
+	if err != nil {
+		return "", err
+	}
+
+	// serial types are not types, they can only be used for creating columns.
+	typ = p.NormalizeType(typ)
+	column := f.ColumnName()
+	if typ == "interval" && indirect(f.Type()) == durationType {
+		// durations were stored as bigint nanoseconds before they were
+		// mapped to interval.
+		return fmt.Sprintf("ALTER TABLE %s ALTER COLUMN %s TYPE interval USING %s / 1000 * interval '1 microsecond';",
+			t.Name(), column, column), nil
+	}
+	return fmt.Sprintf("ALTER TABLE %s ALTER COLUMN %s TYPE %s USING %s::%s;",
+		t.Name(), column, typ, column, typ), nil
+}
+
+// Truncate returns sql query for removing all rows of table.
+func (p *postgresql) Truncate(table string, restartIdentity bool) (string, error) {
+	query := "TRUNCATE TABLE " + table
+	if restartIdentity {
+		query += " RESTART IDENTITY"
+	}
+	return query + ";", nil
+}
+
+// CreateIndex returns sql query for creating index idx if it does not exist.
+func (p *postgresql) CreateIndex(idx Index) (string, error) {
+	buf := &bytes.Buffer{}
+	_, _ = buf.WriteString("CREATE ")
+	if idx.Unique {
+		_, _ = buf.WriteString("UNIQUE ")
+	}
+	_, _ = buf.WriteString("INDEX IF NOT EXISTS " + idx.Name + " ON " + idx.Table)
+	if idx.Method != "" {
+		_, _ = buf.WriteString(" USING " + idx.Method)
+	}
+	_, _ = buf.WriteString(" (" + strings.Join(idx.Columns, ", ") + ")")
+	if idx.Where != "" {
+		_, _ = buf.WriteString(" WHERE " + idx.Where)
+	}
+	_, _ = buf.WriteString(";")
+	return buf.String(), nil
+}
+
+// DropIndex returns sql query for dropping index idx.
+func (p *postgresql) DropIndex(idx Index) (string, error) {
+	return "DROP INDEX IF EXISTS " + idx.Name + ";", nil
+}
+
 // Field returns sql representation of field f..
 func (p *postgresql) Field(f Field) (string, error) {
+	typ, err := p.ColumnType(f)
+	if err != nil {
+		return "", err
+	}
+	return f.ColumnName() + " " + typ + columnConstraints(f), nil
+}
+
+// ColumnType returns the database type of the column for field f.
+func (p *postgresql) ColumnType(f Field) (string, error) {
+	if typ, ok := flagValue(f, specialTags.fieldType); ok && typ != "" {
+		return typ, nil
+	}
+	if isJSON(f) {
+		return "jsonb", nil
+	}
+	if typ := sqlType(f.Type(), p.Name()); typ != "" {
+		return typ, nil
+	}
+	if precision, ok := flagValue(f, specialTags.precision); ok && precision != "" {
+		if !decimalKind(indirect(f.Type()).Kind()) {
+			return "", fmt.Errorf("precision is not supported for field %s of type %s", f.Name(), f.Type())
+		}
+		return "numeric(" + precision + ")", nil
+	}
+	if size, ok := flagValue(f, specialTags.size); ok && size != "" && indirect(f.Type()).Kind() == reflect.String {
+		return "varchar(" + size + ")", nil
+	}
+	typ, ok := p.registry().lookup(f.Type())
+	if !ok && isArray(indirect(f.Type())) {
+		// slices of named types use the column type of their elements.
+		typ, ok = p.registry().lookup(indirect(f.Type()).Elem())
+		typ += "[]"
+	}
+	if !ok {
+		return "", fmt.Errorf(" unknown type %s for field %s", f.Type(), f.Name())
+	}
+	if serial, ok := serialTypes[typ]; ok && hasFlag(f, specialTags.auto) {
+		return serial, nil
+	}
+	return typ, nil
+}
+
+// typeAliases maps postgres type names to the names used by information_schema.
+var typeAliases = map[string]string{
+	"serial":      "integer",
+	"serial4":     "integer",
+	"int":         "integer",
+	"int4":        "integer",
+	"bigserial":   "bigint",
+	"serial8":     "bigint",
+	"int8":        "bigint",
+	"smallserial": "smallint",
+	"serial2":     "smallint",
+	"int2":        "smallint",
+	"bool":        "boolean",
+	"varchar":     "character varying",
+	"char":        "character",
+	"decimal":     "numeric",
+	"float8":      "double precision",
+	"float4":      "real",
+	"timestamptz": "timestamp with time zone",
+	"timestamp":   "timestamp without time zone",
+	"timetz":      "time with time zone",
+	"time":        "time without time zone",
+}
+
+// NormalizeType returns the name of typ as reported by information_schema, for
+// instance varchar(64) becomes character varying(64).
+func (p *postgresql) NormalizeType(typ string) string {
+	typ = strings.ToLower(strings.Join(strings.Fields(typ), " "))
+	if strings.HasSuffix(typ, "[]") {
+		return p.NormalizeType(strings.TrimSuffix(typ, "[]")) + "[]"
+	}
+	var size string
+	if n := strings.Index(typ, "("); n > 0 {
+		typ, size = strings.TrimSpace(typ[:n]), strings.Replace(typ[n:], " ", "", -1)
+	}
+	if v, ok := typeAliases[typ]; ok {
+		typ = v
+	}
+	return typ + size
+}
+
+// AddColumn returns sql query for adding the column of field f to table t.
+// References declared by the field are added with the column.
+func (p *postgresql) AddColumn(t Table, f Field) (string, error) {
+	column, err := p.Field(f)
+	if err != nil {
+		return "", err
+	}
 	buf := &bytes.Buffer{}
-	fName := f.ColumnName()
-	_, _ = buf.WriteString(fName + " ")
-	var details string
-	switch f.Type().Kind() {
-	case reflect.String:
-		details = "text"
-	case reflect.Bool:
-		details = "boolean"
-	case reflect.Int:
-		if strings.ToLower(f.Name()) == "id" {
-			details = "serial"
-			break
-		}
-		details = "integer"
-	case reflect.Int64:
-		if strings.ToLower(f.Name()) == "id" {
-			details = "bigserial"
-			break
-		}
-		details = "bigint"
-	case reflect.Struct:
-		if f.Type().AssignableTo(reflect.TypeOf(time.Time{})) {
-			details = "timestamp with time zone"
-		}
-	}
-	if details == "" {
-		return "", fmt.Errorf(" unknown type for field %s", f.Type().Kind())
-	}
-	_, _ = buf.WriteString(details)
+	_, _ = buf.WriteString("ALTER TABLE " + t.Name() + " ADD COLUMN IF NOT EXISTS " + column)
+	for _, v := range foreignKeys(t) {
+		if v.Column == f.ColumnName() {
+			_, _ = buf.WriteString(references(v))
+		}
+	}
+	_, _ = buf.WriteString(";")
 	return buf.String(), nil
 }
 
+// AddForeignKey returns sql query for adding the foreign key fk to table. The
+// constraint is named the way postgres names foreign keys declared with the
+// table.
+func (p *postgresql) AddForeignKey(table string, fk ForeignKey) (string, error) {
+	return fmt.Sprintf("ALTER TABLE %s ADD CONSTRAINT %s_%s_fkey FOREIGN KEY (%s)%s;",
+		table, table, fk.Column, fk.Column, references(fk)), nil
+}
+
+// Inspect returns the schema of the tables in the current schema of the
+// database.
+func (p *postgresql) Inspect(s *SQL) (*Schema, error) {
+	schema := &Schema{}
+	rows, err := s.Query(`SELECT table_name FROM information_schema.tables
+	WHERE table_schema = current_schema() AND table_type = 'BASE TABLE'
+	ORDER BY table_name;`)
+	if err != nil {
+		return nil, err
+	}
+	defer func() { _ = rows.Close() }()
+	for rows.Next() {
+		t := &TableSchema{}
+		if err = rows.Scan(&t.Name); err != nil {
+			return nil, err
+		}
+		schema.Tables = append(schema.Tables, t)
+	}
+	if err = rows.Err(); err != nil {
+		return nil, err
+	}
+	err = p.inspectColumns(s, schema)
+	if err != nil {
+		return nil, err
+	}
+	err = p.inspectIndexes(s, schema)
+	if err != nil {
+		return nil, err
+	}
+	err = p.inspectForeignKeys(s, schema)
+	if err != nil {
+		return nil, err
+	}
+	return schema, nil
+}
+
+func (p *postgresql) inspectColumns(s *SQL, schema *Schema) error {
+	rows, err := s.Query(`SELECT table_name, column_name, data_type, udt_name,
+	character_maximum_length, numeric_precision, numeric_scale,
+	is_nullable, column_default
+	FROM information_schema.columns WHERE table_schema = current_schema()
+	ORDER BY table_name, ordinal_position;`)
+	if err != nil {
+		return err
+	}
+	defer func() { _ = rows.Close() }()
+	for rows.Next() {
+		var table, udt, nullable string
+		var size, precision, scale sql.NullInt64
+		var def sql.NullString
+		c := &ColumnSchema{}
+		err = rows.Scan(&table, &c.Name, &c.Type, &udt, &size, &precision, &scale, &nullable, &def)
+		if err != nil {
+			return err
+		}
+		switch {
+		case c.Type == "ARRAY":
+			// the udt name of arrays is the element type prefixed with _
+			c.Type = p.NormalizeType(strings.TrimPrefix(udt, "_")) + "[]"
+		case size.Valid:
+			c.Type = fmt.Sprintf("%s(%d)", c.Type, size.Int64)
+		case c.Type == "numeric" && precision.Valid:
+			c.Type = fmt.Sprintf("numeric(%d,%d)", precision.Int64, scale.Int64)
+		}
+		c.Nullable = nullable == "YES"
+		c.Default = def.String
+		if t := schema.Table(table); t != nil {
+			t.Columns = append(t.Columns, c)
+		}
+	}
+	return rows.Err()
+}
+
+// inspectIndexes adds the indexes to the tables of schema. The columns of the
+// primary key index are the primary key of the table.
+func (p *postgresql) inspectIndexes(s *SQL, schema *Schema) error {
+	rows, err := s.Query(`SELECT t.relname, i.relname, ix.indisunique, ix.indisprimary,
+	am.amname, COALESCE(pg_get_expr(ix.indpred, ix.indrelid), ''),
+	array_to_string(ARRAY(SELECT pg_get_indexdef(ix.indexrelid, k, true)
+		FROM generate_series(1, ix.indnkeyatts) AS k ORDER BY k), E'\n')
+	FROM pg_index ix
+	JOIN pg_class i ON i.oid = ix.indexrelid
+	JOIN pg_class t ON t.oid = ix.indrelid
+	JOIN pg_am am ON am.oid = i.relam
+	JOIN pg_namespace n ON n.oid = t.relnamespace
+	WHERE n.nspname = current_schema() ORDER BY t.relname, i.relname;`)
+	if err != nil {
+		return err
+	}
+	defer func() { _ = rows.Close() }()
+	for rows.Next() {
+		var columns string
+		var primary bool
+		idx := Index{}
+		err = rows.Scan(&idx.Table, &idx.Name, &idx.Unique, &primary, &idx.Method, &idx.Where, &columns)
+		if err != nil {
+			return err
+		}
+		idx.Columns = strings.Split(columns, "\n")
+		if idx.Method == "btree" {
+			idx.Method = ""
+		}
+		t := schema.Table(idx.Table)
+		if t == nil {
+			continue
+		}
+		if primary {
+			t.PrimaryKey = idx.Columns
+		}
+		t.Indexes = append(t.Indexes, idx)
+	}
+	return rows.Err()
+}
+
+// inspectForeignKeys adds the foreign keys to the tables of schema. Foreign keys
+// made of several columns have an entry for each column.
+func (p *postgresql) inspectForeignKeys(s *SQL, schema *Schema) error {
+	rows, err := s.Query(`SELECT cl.relname, con.conname, a.attname, rcl.relname,
+	ra.attname, con.confdeltype, con.confupdtype
+	FROM pg_constraint con
+	JOIN pg_class cl ON cl.oid = con.conrelid
+	JOIN pg_namespace n ON n.oid = cl.relnamespace
+	JOIN pg_class rcl ON rcl.oid = con.confrelid
+	CROSS JOIN LATERAL unnest(con.conkey, con.confkey) WITH ORDINALITY AS k(col, refcol, pos)
+	JOIN pg_attribute a ON a.attrelid = con.conrelid AND a.attnum = k.col
+	JOIN pg_attribute ra ON ra.attrelid = con.confrelid AND ra.attnum = k.refcol
+	WHERE con.contype = 'f' AND n.nspname = current_schema()
+	ORDER BY cl.relname, con.conname, k.pos;`)
+	if err != nil {
+		return err
+	}
+	defer func() { _ = rows.Close() }()
+	for rows.Next() {
+		var table, onDelete, onUpdate string
+		fk := ForeignKey{}
+		err = rows.Scan(&table, &fk.Name, &fk.Column, &fk.RefTable, &fk.RefColumn, &onDelete, &onUpdate)
+		if err != nil {
+			return err
+		}
+		fk.OnDelete = referentialAction(onDelete)
+		fk.OnUpdate = referentialAction(onUpdate)
+		if t := schema.Table(table); t != nil {
+			t.ForeignKeys = append(t.ForeignKeys, fk)
+		}
+	}
+	return rows.Err()
+}
+
+// referentialAction returns the action for the code used by pg_constraint,
+// NO ACTION is the default and it is returned as an empty string.
+func referentialAction(code string) string {
+	switch code {
+	case "r":
+		return "RESTRICT"
+	case "c":
+		return "CASCADE"
+	case "n":
+		return "SET NULL"
+	case "d":
+		return "SET DEFAULT"
+	}
+	return ""
+}
+
+// GoType returns the Go type for the column c. Types which are not created for
+// Go types by default are set with the type option.
+func (p *postgresql) GoType(c *ColumnSchema) (string, []string) {
+	typ := p.NormalizeType(c.Type)
+	base, args := typ, ""
+	if n := strings.Index(typ, "("); n > 0 && strings.HasSuffix(typ, ")") {
+		base, args = typ[:n], typ[n+1:len(typ)-1]
+	}
+	option := func(v string) []string {
+		return []string{specialTags.fieldType + ":" + v}
+	}
+	if strings.HasSuffix(typ, "[]") {
+		switch typ {
+		case "text[]":
+			return "[]string", nil
+		case "integer[]":
+			return "[]int", nil
+		case "bigint[]":
+			return "[]int64", nil
+		case "smallint[]":
+			return "[]int16", nil
+		case "boolean[]":
+			return "[]bool", nil
+		case "real[]":
+			return "[]float32", nil
+		case "double precision[]":
+			return "[]float64", nil
+		}
+		return "[]string", option(typ)
+	}
+	switch base {
+	case "integer":
+		return "int", nil
+	case "bigint":
+		return "int64", nil
+	case "smallint":
+		return "int16", nil
+	case "boolean":
+		return "bool", nil
+	case "text":
+		return "string", nil
+	case "character varying":
+		if args != "" {
+			return "string", []string{specialTags.size + ":" + args}
+		}
+		return "string", option(typ)
+	case "numeric":
+		if args != "" {
+			return "float64", []string{specialTags.precision + ":" + args}
+		}
+		return "float64", option(typ)
+	case "real":
+		return "float32", nil
+	case "double precision":
+		return "float64", nil
+	case "timestamp with time zone":
+		return "time.Time", nil
+	case "timestamp without time zone", "date":
+		return "time.Time", option(typ)
+	case "bytea":
+		return "[]byte", nil
+	case "uuid":
+		return "orange.UUID", nil
+	case "inet":
+		return "net.IP", nil
+	case "interval":
+		return "time.Duration", nil
+	}
+	return "string", option(typ)
+}
+
 func (p *postgresql) Quote(pos int) string {
 	return fmt.Sprintf("$%d", pos)
 }
@@ -94,6 +570,40 @@ func (p *postgresql) Database(s *SQL) string {
 	return name
 }
 
+// CreateDatabase returns sql for creating the database name.
+func (p *postgresql) CreateDatabase(name string) (string, error) {
+	if err := databaseName(name); err != nil {
+		return "", err
+	}
+	return "CREATE DATABASE " + name + ";", nil
+}
+
+// DropDatabase returns sql for dropping the database name if it exists.
+func (p *postgresql) DropDatabase(name string) (string, error) {
+	if err := databaseName(name); err != nil {
+		return "", err
+	}
+	return "DROP DATABASE IF EXISTS " + name + ";", nil
+}
+
+// Explain returns EXPLAIN query for query.
+func (p *postgresql) Explain(query string) string {
+	return "EXPLAIN " + query
+}
+
+// TryLock tries to obtain the advisory lock for name.
+func (p *postgresql) TryLock(ctx context.Context, conn *sql.Conn, name string) (bool, error) {
+	var ok bool
+	err := conn.QueryRowContext(ctx, "SELECT pg_try_advisory_lock($1);", lockKey(name)).Scan(&ok)
+	return ok, err
+}
+
+// Unlock releases the advisory lock for name.
+func (p *postgresql) Unlock(ctx context.Context, conn *sql.Conn, name string) error {
+	_, err := conn.ExecContext(ctx, "SELECT pg_advisory_unlock($1);", lockKey(name))
+	return err
+}
+
 func (p *postgresql) HasPrepare() bool {
 	return true
 }
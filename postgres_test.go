@@ -22,10 +22,24 @@ func TestPostgres_Create(t *testing.T) {
 	if err != nil {
 		t.Fatal(err)
 	}
-	expect := "CREATE TABLE IF NOT EXISTS postgres_test (id serial,body text,created_at timestamp with time zone,updated_at timestamp with time zone);"
+	expect := "CREATE TABLE IF NOT EXISTS postgres_test (id serial,body text,created_at timestamp with time zone,updated_at timestamp with time zone,PRIMARY KEY (id));"
 	if create != expect {
 		t.Errorf("expected %s got %s", expect, create)
 	}
+
+	// integers can not hold the scale of the numeric
+	type invalid struct {
+		ID      int64
+		Balance int64 `sql:"precision:12,2"`
+	}
+	tab, err = loadTable(&invalid{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, err = p.Create(tab)
+	if err == nil {
+		t.Error("expected an error for precision on an integer field")
+	}
 }
 
 func TestPostgres_Drop(t *testing.T) {
@@ -43,3 +57,128 @@ func TestPostgres_Drop(t *testing.T) {
 		t.Errorf("expected %s got %s", expect, drop)
 	}
 }
+
+func TestPostgres_Field(t *testing.T) {
+	p := &postgresql{}
+	tab, err := loadTable(&taggedModel{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	fields, err := tab.Fields()
+	if err != nil {
+		t.Fatal(err)
+	}
+	column, err := p.Field(fields[1])
+	if err != nil {
+		t.Fatal(err)
+	}
+	expect := "user_name varchar(64) NOT NULL DEFAULT 'x:y'"
+	if column != expect {
+		t.Errorf("expected %s got %s", expect, column)
+	}
+}
+
+type constrained struct {
+	ID      int64
+	Email   string  `sql:"size:128;not null;unique"`
+	Age     int     `sql:"not null;default:0;check:age >= 0"`
+	Balance float64 `sql:"precision:12,2"`
+	Active  bool    `sql:"default:true"`
+	Bio     string  `sql:"type:text;check:length(bio) < 500"`
+}
+
+func TestPostgres_Constraints(t *testing.T) {
+	p := &postgresql{}
+	tab, err := loadTable(&constrained{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	create, err := p.Create(tab)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expect := "CREATE TABLE IF NOT EXISTS constrained (id bigserial," +
+		"email varchar(128) NOT NULL UNIQUE," +
+		"age integer NOT NULL DEFAULT 0 CHECK (age >= 0)," +
+		"balance numeric(12,2)," +
+		"active boolean DEFAULT true," +
+		"bio text CHECK (length(bio) < 500)," +
+		"PRIMARY KEY (id));"
+	if create != expect {
+		t.Errorf("expected %s got %s", expect, create)
+	}
+}
+
+type membership struct {
+	Team     int64  `sql:"pk"`
+	UserCode string `sql:"pk"`
+	Role     string
+}
+
+type session struct {
+	Token string `sql:"pk"`
+	Data  string
+}
+
+func TestPostgres_PrimaryKey(t *testing.T) {
+	p := &postgresql{}
+	sample := []struct {
+		model  interface{}
+		expect string
+	}{
+		{&membership{}, "CREATE TABLE IF NOT EXISTS membership (team bigint,user_code text,role text,PRIMARY KEY (team, user_code));"},
+		{&session{}, "CREATE TABLE IF NOT EXISTS session (token text,data text,PRIMARY KEY (token));"},
+	}
+	for _, v := range sample {
+		tab, err := loadTable(v.model)
+		if err != nil {
+			t.Fatal(err)
+		}
+		create, err := p.Create(tab)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if create != v.expect {
+			t.Errorf("expected %s got %s", v.expect, create)
+		}
+	}
+}
+
+func TestReferentialAction(t *testing.T) {
+	sample := []struct {
+		code, expect string
+	}{
+		{"a", ""},
+		{"r", "RESTRICT"},
+		{"c", "CASCADE"},
+		{"n", "SET NULL"},
+		{"d", "SET DEFAULT"},
+	}
+	for _, v := range sample {
+		if got := referentialAction(v.code); got != v.expect {
+			t.Errorf("expected %s got %s", v.expect, got)
+		}
+	}
+}
+
+func TestPostgres_CreateDatabase(t *testing.T) {
+	p := &postgresql{}
+	query, err := p.CreateDatabase("orange_test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if query != "CREATE DATABASE orange_test;" {
+		t.Errorf("expected CREATE DATABASE orange_test; got %s", query)
+	}
+	query, err = p.DropDatabase("orange_test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if query != "DROP DATABASE IF EXISTS orange_test;" {
+		t.Errorf("expected DROP DATABASE IF EXISTS orange_test; got %s", query)
+	}
+	_, err = p.CreateDatabase("test; DROP TABLE users")
+	if err == nil {
+		t.Error("expected an error for an invalid name")
+	}
+}
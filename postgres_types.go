@@ -0,0 +1,304 @@
+package orange
+
+import (
+	"database/sql"
+	"fmt"
+	"math/big"
+	"net"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+)
+
+var (
+	uuidType     = reflect.TypeOf(UUID{})
+	ratType      = reflect.TypeOf(big.Rat{})
+	ipType       = reflect.TypeOf(net.IP(nil))
+	durationType = reflect.TypeOf(time.Duration(0))
+)
+
+// intervals returns true if time.Duration is stored as interval, which is the
+// default. Registering another type keeps durations as nanoseconds e.g for
+// bigint columns created before durations were mapped to interval.
+//
+//	db.RegisterType(time.Duration(0), "bigint")
+func (p *postgresql) intervals() bool {
+	typ, _ := p.registry().lookup(durationType)
+	return p.NormalizeType(typ) == "interval"
+}
+
+// ConvertValue converts slices to array literals, [16]byte to uuid, net.IP to
+// inet, big.Rat to numeric and time.Duration to interval unless durations are
+// registered as another type.
+func (p *postgresql) ConvertValue(v interface{}) (interface{}, bool) {
+	switch value := v.(type) {
+	case net.IP:
+		if value == nil {
+			return nil, true
+		}
+		return value.String(), true
+	case time.Duration:
+		if !p.intervals() {
+			return nil, false
+		}
+		return formatInterval(value), true
+	case *big.Rat:
+		if value == nil {
+			return nil, true
+		}
+		return formatRat(value), true
+	case big.Rat:
+		return formatRat(&value), true
+	}
+	val := reflect.ValueOf(v)
+	switch {
+	case !val.IsValid():
+		return nil, false
+	case val.Kind() == reflect.Array && val.Type().ConvertibleTo(uuidType):
+		v, _ := val.Convert(uuidType).Interface().(UUID).Value()
+		return v, true
+	case isArray(val.Type()):
+		return arrayLiteral(val), true
+	}
+	return nil, false
+}
+
+// Scanner returns scanners for the types converted by ConvertValue. Pointers
+// to these types are set to nil for NULL.
+func (p *postgresql) Scanner(dest interface{}) sql.Scanner {
+	val := reflect.ValueOf(dest)
+	if val.Kind() != reflect.Ptr || val.IsNil() {
+		return nil
+	}
+	typ := val.Type().Elem()
+	if typ.Kind() == reflect.Ptr {
+		inner := reflect.New(typ.Elem())
+		if sc := p.Scanner(inner.Interface()); sc != nil {
+			return &nullScanner{dest: val, value: inner, scanner: sc}
+		}
+		return nil
+	}
+	switch {
+	case reflect.PtrTo(typ).Implements(scannerType):
+		return nil
+	case typ == ipType:
+		return &ipScanner{dest: dest.(*net.IP)}
+	case typ == durationType && p.intervals():
+		return &intervalScanner{dest: dest.(*time.Duration)}
+	case typ == ratType:
+		return &ratScanner{dest: dest.(*big.Rat)}
+	case typ.Kind() == reflect.Array && typ.ConvertibleTo(uuidType):
+		return &uuidScanner{dest: val}
+	case isArray(typ):
+		return &arrayScanner{dest: val}
+	}
+	return nil
+}
+
+// nullScanner scans into a pointer, which is nil for NULL.
+type nullScanner struct {
+	dest    reflect.Value
+	value   reflect.Value
+	scanner sql.Scanner
+}
+
+func (n *nullScanner) Scan(src interface{}) error {
+	if src == nil {
+		n.dest.Elem().Set(reflect.Zero(n.dest.Elem().Type()))
+		return nil
+	}
+	err := n.scanner.Scan(src)
+	if err != nil {
+		return err
+	}
+	n.dest.Elem().Set(n.value)
+	return nil
+}
+
+// scanText returns the text of src, which is a string or []byte.
+func scanText(src interface{}, kind string) (string, error) {
+	switch v := src.(type) {
+	case string:
+		return v, nil
+	case []byte:
+		return string(v), nil
+	}
+	return "", fmt.Errorf("can not scan %T as %s", src, kind)
+}
+
+// uuidScanner scans uuid columns into [16]byte.
+type uuidScanner struct {
+	dest reflect.Value
+}
+
+func (u *uuidScanner) Scan(src interface{}) error {
+	var id UUID
+	err := id.Scan(src)
+	if err != nil {
+		return err
+	}
+	u.dest.Elem().Set(reflect.ValueOf(id).Convert(u.dest.Elem().Type()))
+	return nil
+}
+
+// ipScanner scans inet columns, the network mask of the address is discarded.
+type ipScanner struct {
+	dest *net.IP
+}
+
+func (i *ipScanner) Scan(src interface{}) error {
+	if src == nil {
+		*i.dest = nil
+		return nil
+	}
+	text, err := scanText(src, "inet")
+	if err != nil {
+		return err
+	}
+	if n := strings.IndexByte(text, '/'); n >= 0 {
+		text = text[:n]
+	}
+	ip := net.ParseIP(text)
+	if ip == nil {
+		return fmt.Errorf("invalid inet %s", text)
+	}
+	*i.dest = ip
+	return nil
+}
+
+// ratScanner scans numeric columns into big.Rat.
+type ratScanner struct {
+	dest *big.Rat
+}
+
+func (r *ratScanner) Scan(src interface{}) error {
+	if src == nil {
+		r.dest.SetInt64(0)
+		return nil
+	}
+	var text string
+	switch v := src.(type) {
+	case float64:
+		r.dest.SetFloat64(v)
+		return nil
+	case int64:
+		r.dest.SetInt64(v)
+		return nil
+	default:
+		var err error
+		text, err = scanText(src, "numeric")
+		if err != nil {
+			return err
+		}
+	}
+	if _, ok := r.dest.SetString(text); !ok {
+		return fmt.Errorf("invalid numeric %s", text)
+	}
+	return nil
+}
+
+// formatRat returns the decimal form of r. Numbers which have no finite
+// decimal form are rounded to 30 decimal places.
+func formatRat(r *big.Rat) string {
+	s := r.FloatString(30)
+	s = strings.TrimRight(s, "0")
+	return strings.TrimSuffix(s, ".")
+}
+
+// intervalScanner scans interval columns into time.Duration.
+type intervalScanner struct {
+	dest *time.Duration
+}
+
+func (i *intervalScanner) Scan(src interface{}) error {
+	if src == nil {
+		*i.dest = 0
+		return nil
+	}
+	text, err := scanText(src, "interval")
+	if err != nil {
+		return err
+	}
+	d, err := parseInterval(text)
+	if err != nil {
+		return err
+	}
+	*i.dest = d
+	return nil
+}
+
+// formatInterval returns the interval literal of d.
+func formatInterval(d time.Duration) string {
+	return fmt.Sprintf("%d microseconds", d/time.Microsecond)
+}
+
+// intervalUnits are the durations of the units of the postgres interval
+// output. Like justify_interval a month is 30 days.
+var intervalUnits = map[string]time.Duration{
+	"year":  12 * 30 * 24 * time.Hour,
+	"years": 12 * 30 * 24 * time.Hour,
+	"mon":   30 * 24 * time.Hour,
+	"mons":  30 * 24 * time.Hour,
+	"day":   24 * time.Hour,
+	"days":  24 * time.Hour,
+}
+
+// parseInterval parses the postgres output of intervals e.g
+// 1 day -02:03:04.5 or 3 mons 00:00:01.
+func parseInterval(s string) (time.Duration, error) {
+	var d time.Duration
+	fields := strings.Fields(s)
+	for i := 0; i < len(fields); i++ {
+		v := fields[i]
+		if strings.Contains(v, ":") {
+			t, err := parseClock(v)
+			if err != nil {
+				return 0, fmt.Errorf("invalid interval %s", s)
+			}
+			d += t
+			continue
+		}
+		if i+1 >= len(fields) {
+			return 0, fmt.Errorf("invalid interval %s", s)
+		}
+		n, err := strconv.ParseInt(v, 10, 64)
+		unit, ok := intervalUnits[fields[i+1]]
+		if err != nil || !ok {
+			return 0, fmt.Errorf("invalid interval %s", s)
+		}
+		d += time.Duration(n) * unit
+		i++
+	}
+	return d, nil
+}
+
+// parseClock parses [-]hh:mm:ss[.ffffff].
+func parseClock(s string) (time.Duration, error) {
+	sign := time.Duration(1)
+	switch {
+	case strings.HasPrefix(s, "-"):
+		sign, s = -1, s[1:]
+	case strings.HasPrefix(s, "+"):
+		s = s[1:]
+	}
+	parts := strings.Split(s, ":")
+	if len(parts) != 3 {
+		return 0, fmt.Errorf("invalid time %s", s)
+	}
+	h, err := strconv.ParseInt(parts[0], 10, 64)
+	if err != nil {
+		return 0, err
+	}
+	m, err := strconv.ParseInt(parts[1], 10, 64)
+	if err != nil {
+		return 0, err
+	}
+	sec, err := strconv.ParseFloat(parts[2], 64)
+	if err != nil {
+		return 0, err
+	}
+	d := time.Duration(h)*time.Hour + time.Duration(m)*time.Minute +
+		time.Duration(sec*float64(time.Second)+0.5)
+	return sign * d, nil
+}
@@ -0,0 +1,267 @@
+package orange
+
+import (
+	"math/big"
+	"net"
+	"reflect"
+	"testing"
+	"time"
+)
+
+type device struct {
+	ID      UUID
+	Serial  [16]byte
+	Tags    []string
+	Ports   []int64
+	Labels  []status
+	Addr    net.IP
+	Price   *big.Rat `sql:"precision:10,2"`
+	Timeout time.Duration
+}
+
+func TestPostgres_Types(t *testing.T) {
+	db, err := Open("postgres", testDB.ps)
+	if err != nil {
+		t.Fatal(err)
+	}
+	query, err := db.adopter.Create(mustLoad(t, &device{}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	create := "CREATE TABLE IF NOT EXISTS device (id uuid,serial uuid,tags text[],ports bigint[],labels text[],addr inet,price numeric(10,2),timeout interval,PRIMARY KEY (id));"
+	if query != create {
+		t.Errorf("expected %s got %s", create, query)
+	}
+	id, err := ParseUUID("6ba7b810-9dad-11d1-80b4-00c04fd430c8")
+	if err != nil {
+		t.Fatal(err)
+	}
+	dry := db.DryRun()
+	err = dry.Create(&device{
+		ID:      id,
+		Tags:    []string{"a b", `it's "q"`},
+		Ports:   []int64{80, 443},
+		Addr:    net.ParseIP("10.0.0.1"),
+		Price:   big.NewRat(1999, 100),
+		Timeout: 1500 * time.Millisecond,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	insert := `INSERT INTO device (id, tags, ports, addr, price, timeout) VALUES ('6ba7b810-9dad-11d1-80b4-00c04fd430c8', '{"a b","it''s \"q\""}', '{80,443}', '10.0.0.1', '19.99', '1500000 microseconds');`
+	if got := dry.Statements()[0].Query; got != insert {
+		t.Errorf("expected %s got %s", insert, got)
+	}
+
+	d := &device{}
+	row := driverScanner{
+		[]byte("6ba7b810-9dad-11d1-80b4-00c04fd430c8"),
+		"6ba7b810-9dad-11d1-80b4-00c04fd430c8",
+		[]byte(`{"a b","it's \"q\"",NULL}`),
+		[]byte("{80,443}"),
+		[]byte("{active}"),
+		[]byte("10.0.0.1/32"),
+		[]byte("19.99"),
+		[]byte("1 day 00:00:01.5"),
+	}
+	err = db.scanStruct(row, []string{"id", "serial", "tags", "ports", "labels", "addr", "price", "timeout"}, d)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expect := &device{
+		ID:      id,
+		Serial:  id,
+		Tags:    []string{"a b", `it's "q"`, ""},
+		Ports:   []int64{80, 443},
+		Labels:  []status{"active"},
+		Addr:    net.ParseIP("10.0.0.1"),
+		Price:   big.NewRat(1999, 100),
+		Timeout: 24*time.Hour + 1500*time.Millisecond,
+	}
+	if !reflect.DeepEqual(d, expect) {
+		t.Errorf("expected %v got %v", expect, d)
+	}
+}
+
+func TestPostgres_GoType(t *testing.T) {
+	p := &postgresql{}
+	sample := []struct {
+		typ, expect string
+	}{
+		{"text[]", "[]string"},
+		{"bigint[]", "[]int64"},
+		{"uuid", "orange.UUID"},
+		{"inet", "net.IP"},
+		{"interval", "time.Duration"},
+	}
+	for _, v := range sample {
+		if typ, _ := p.GoType(&ColumnSchema{Type: v.typ}); typ != v.expect {
+			t.Errorf("expected %s got %s", v.expect, typ)
+		}
+	}
+}
+
+func TestParseInterval(t *testing.T) {
+	sample := []struct {
+		text   string
+		expect time.Duration
+	}{
+		{"00:00:00", 0},
+		{"01:02:03", time.Hour + 2*time.Minute + 3*time.Second},
+		{"-00:00:00.25", -250 * time.Millisecond},
+		{"2 days", 48 * time.Hour},
+		{"1 mon -1 days +02:00:00", 29*24*time.Hour + 2*time.Hour},
+		{"1 year", 360 * 24 * time.Hour},
+	}
+	for _, v := range sample {
+		got, err := parseInterval(v.text)
+		if err != nil {
+			t.Errorf("%s: %v", v.text, err)
+			continue
+		}
+		if got != v.expect {
+			t.Errorf("expected %s got %s", v.expect, got)
+		}
+	}
+	_, err := parseInterval("3 weeks")
+	if err == nil {
+		t.Error("expected an error for an unknown unit")
+	}
+}
+
+func TestSQL_WhereArray(t *testing.T) {
+	db, err := Open("postgres", testDB.ps)
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = db.Register(&device{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	sample := []struct {
+		query  *SQL
+		expect string
+	}{
+		{db.Copy().Select(&device{}).WhereAny("ports", []int64{1, 2}),
+			"SELECT * FROM device WHERE ports = ANY('{1,2}');"},
+		{db.Copy().Select(&device{}).WhereArrayContains("tags", []string{"go"}),
+			`SELECT * FROM device WHERE tags @> '{"go"}';`},
+		{db.Copy().Select(&device{}).WhereAny("tags", []string{"a"}).WhereArrayContains("ports", []int64{80}),
+			`SELECT * FROM device WHERE tags = ANY('{"a"}') AND ports @> '{80}';`},
+	}
+	for _, v := range sample {
+		query, _, err := v.query.BuildQuery()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if query != v.expect {
+			t.Errorf("expected %s got %s", v.expect, query)
+		}
+	}
+}
+
+type job struct {
+	ID      int64
+	Timeout time.Duration
+}
+
+func TestPostgres_Duration(t *testing.T) {
+	db, err := Open("postgres", testDB.ps)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tab := mustLoad(t, &job{})
+
+	// bigint columns of durations are migrated to interval.
+	live := &TableSchema{
+		Name: "job",
+		Columns: []*ColumnSchema{
+			{Name: "id", Type: "bigint"},
+			{Name: "timeout", Type: "bigint", Nullable: true},
+		},
+	}
+	diffs, err := db.diffTable(tab, live)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expect := []Difference{
+		{Kind: TypeMismatch, Table: "job", Column: "timeout", Expected: "interval", Actual: "bigint"},
+	}
+	if !reflect.DeepEqual(diffs, expect) {
+		t.Errorf("expected %v got %v", expect, diffs)
+	}
+	dry := db.DryRun()
+	err = dry.AlterColumnType(&job{}, "timeout")
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = dry.Create(&job{ID: 1, Timeout: 1500 * time.Millisecond})
+	if err != nil {
+		t.Fatal(err)
+	}
+	sample := []string{
+		"ALTER TABLE job ALTER COLUMN timeout TYPE interval USING timeout / 1000 * interval '1 microsecond';",
+		"INSERT INTO job (id, timeout) VALUES (1, '1500000 microseconds');",
+	}
+	got := dry.Statements()
+	if len(got) != len(sample) {
+		t.Fatalf("expected %d statements got %d", len(sample), len(got))
+	}
+	for k, v := range sample {
+		if got[k].Query != v {
+			t.Errorf("expected %s got %s", v, got[k].Query)
+		}
+	}
+
+	// registering bigint keeps the old columns.
+	db.RegisterType(time.Duration(0), "bigint")
+	query, err := db.adopter.Create(tab)
+	if err != nil {
+		t.Fatal(err)
+	}
+	create := "CREATE TABLE IF NOT EXISTS job (id bigserial,timeout bigint,PRIMARY KEY (id));"
+	if query != create {
+		t.Errorf("expected %s got %s", create, query)
+	}
+	diffs, err = db.diffTable(tab, live)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(diffs) != 0 {
+		t.Errorf("expected no differences got %v", diffs)
+	}
+	dry = db.DryRun()
+	err = dry.Create(&job{ID: 1, Timeout: 1500 * time.Millisecond})
+	if err != nil {
+		t.Fatal(err)
+	}
+	insert := "INSERT INTO job (id, timeout) VALUES (1, 1500000000);"
+	if got := dry.Statements()[0].Query; got != insert {
+		t.Errorf("expected %s got %s", insert, got)
+	}
+	var d time.Duration
+	if db.adopter.Scanner(&d) != nil {
+		t.Error("expected bigint durations to be scanned as they are")
+	}
+}
+
+func TestSQL_ConvertArgs(t *testing.T) {
+	db, err := Open("postgres", testDB.ps)
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = db.Register(&device{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, args, err := db.Copy().Select(&device{}).
+		Where("tags=$1 AND addr=$2", []string{"go"}, net.ParseIP("10.0.0.1")).
+		BuildQuery()
+	if err != nil {
+		t.Fatal(err)
+	}
+	expect := []interface{}{`{"go"}`, "10.0.0.1"}
+	if !reflect.DeepEqual(args, expect) {
+		t.Errorf("expected %v got %v", expect, args)
+	}
+}
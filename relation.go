@@ -0,0 +1,245 @@
+package orange
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// ForeignKey is a reference from a column to a column of another table.
+type ForeignKey struct {
+	Column    string
+	RefTable  string
+	RefColumn string
+
+	// OnDelete and OnUpdate are the referential actions e.g CASCADE or
+	// SET NULL, the database default is used when they are empty.
+	OnDelete string
+	OnUpdate string
+
+	// Name is the name of the constraint, it is only set for foreign keys of
+	// a live database.
+	Name string
+}
+
+// relatedTable is a Table with resolved foreign keys.
+type relatedTable struct {
+	Table
+	keys []ForeignKey
+}
+
+// ForeignKeys returns the foreign keys of the table.
+func (r *relatedTable) ForeignKeys() []ForeignKey {
+	return r.keys
+}
+
+// Indexes returns the indexes of the resolved table. It is forwarded since the
+// embedded Table does not promote the methods of its dynamic type.
+func (r *relatedTable) Indexes() []Index {
+	if i, ok := r.Table.(Indexer); ok {
+		return i.Indexes()
+	}
+	return nil
+}
+
+// foreignKeys returns the foreign keys of t if it has any.
+func foreignKeys(t Table) []ForeignKey {
+	if r, ok := t.(interface {
+		ForeignKeys() []ForeignKey
+	}); ok {
+		return r.ForeignKeys()
+	}
+	return nil
+}
+
+// loadRelations sets the table flags for the fields of t which reference other
+// models. For every such field there is a flag named relation whose key is the
+// column and the value is the referenced model, the on_delete and on_update
+// options are added the same way.
+//
+//	Owner int64 `sql:"relation:User(id);on_delete:cascade"`
+func (t *table) loadRelations() {
+	for _, f := range t.fields {
+		rel, ok := flagValue(f, specialTags.relation)
+		if !ok || rel == "" {
+			continue
+		}
+		col := f.ColumnName()
+		t.tags = append(t.tags, &tag{name: specialTags.relation, key: col, value: rel})
+		for _, action := range []string{specialTags.onDelete, specialTags.onUpdate} {
+			if v, ok := flagValue(f, action); ok && v != "" {
+				t.tags = append(t.tags, &tag{name: action, key: col, value: v})
+			}
+		}
+	}
+}
+
+// relations returns the relation flags of t.
+func relations(t Table) []Flag {
+	flags, err := t.Flags()
+	if err != nil {
+		return nil
+	}
+	var rst []Flag
+	for _, v := range flags {
+		if v.Name() == specialTags.relation {
+			rst = append(rst, v)
+		}
+	}
+	return rst
+}
+
+// parseRelation splits a relation like User(id) into the model and the
+// column, column is empty when it is not specified.
+func parseRelation(rel string) (model, column string) {
+	rel = strings.TrimSpace(rel)
+	n := strings.Index(rel, "(")
+	if n < 0 || !strings.HasSuffix(rel, ")") {
+		return rel, ""
+	}
+	return strings.TrimSpace(rel[:n]), strings.TrimSpace(rel[n+1 : len(rel)-1])
+}
+
+// relatedModel returns the registered table that rel refers to. The model is
+// looked up by the name of its struct and then by the name of its table.
+func (s *SQL) relatedModel(rel string) (Table, string, error) {
+	name, column := parseRelation(rel)
+	ref := s.getModel(name)
+	if ref == nil {
+		s.mu.RLock()
+		for _, v := range s.models {
+			if v.Name() == name {
+				ref = v
+				break
+			}
+		}
+		s.mu.RUnlock()
+	}
+	if ref == nil {
+		return nil, "", fmt.Errorf("relation %s is not a registered model", name)
+	}
+	if column == "" {
+		keys := primaryKeys(ref)
+		if len(keys) != 1 {
+			return nil, "", fmt.Errorf("relation %s needs a column, %s has no single primary key", rel, ref.Name())
+		}
+		column = keys[0].ColumnName()
+	}
+	return ref, column, nil
+}
+
+// resolve returns t with its relations resolved to foreign keys.
+func (s *SQL) resolve(t Table) (Table, error) {
+	rels := relations(t)
+	if rels == nil {
+		return t, nil
+	}
+	flags, _ := t.Flags()
+	var keys []ForeignKey
+	for _, v := range rels {
+		ref, column, err := s.relatedModel(v.Value())
+		if err != nil {
+			return nil, err
+		}
+		fk := ForeignKey{Column: v.Key(), RefTable: ref.Name(), RefColumn: column}
+		for _, f := range flags {
+			if f.Key() != v.Key() {
+				continue
+			}
+			switch f.Name() {
+			case specialTags.onDelete:
+				fk.OnDelete = strings.ToUpper(f.Value())
+			case specialTags.onUpdate:
+				fk.OnUpdate = strings.ToUpper(f.Value())
+			}
+		}
+		keys = append(keys, fk)
+	}
+	return &relatedTable{Table: t, keys: keys}, nil
+}
+
+// cyclicKeys holds the relations which close a cycle, by table and column.
+// Their foreign keys can not be declared with the table, since the referenced
+// table is created after it.
+type cyclicKeys map[string]map[string]bool
+
+func (c cyclicKeys) add(table, column string) {
+	if c[table] == nil {
+		c[table] = make(map[string]bool)
+	}
+	c[table][column] = true
+}
+
+// split returns t without the foreign keys which close a cycle, and those
+// foreign keys.
+func (c cyclicKeys) split(t Table) (Table, []ForeignKey) {
+	r, ok := t.(*relatedTable)
+	if !ok || c[t.Name()] == nil {
+		return t, nil
+	}
+	var keys, deferred []ForeignKey
+	for _, v := range r.keys {
+		if c[t.Name()][v.Column] {
+			deferred = append(deferred, v)
+			continue
+		}
+		keys = append(keys, v)
+	}
+	return &relatedTable{Table: r.Table, keys: keys}, deferred
+}
+
+// sortedModels returns the registered tables ordered so that every table comes
+// after the tables it references. The order is otherwise alphabetical. When
+// tables reference each other the relations closing the cycle are returned as
+// cyclicKeys.
+func (s *SQL) sortedModels() ([]Table, cyclicKeys, error) {
+	s.mu.RLock()
+	var names []string
+	byTable := make(map[string]string)
+	for k, v := range s.models {
+		names = append(names, k)
+		byTable[v.Name()] = k
+	}
+	s.mu.RUnlock()
+	sort.Strings(names)
+	var rst []Table
+	cyclic := make(cyclicKeys)
+	state := make(map[string]int) // 1 visiting, 2 done
+	var visit func(name string) error
+	visit = func(name string) error {
+		state[name] = 1
+		t := s.getModel(name)
+		for _, v := range relations(t) {
+			ref, _, err := s.relatedModel(v.Value())
+			if err != nil {
+				return err
+			}
+			if ref.Name() == t.Name() {
+				continue
+			}
+			next := byTable[ref.Name()]
+			switch state[next] {
+			case 1:
+				cyclic.add(t.Name(), v.Key())
+				continue
+			case 2:
+				continue
+			}
+			if err := visit(next); err != nil {
+				return err
+			}
+		}
+		state[name] = 2
+		rst = append(rst, t)
+		return nil
+	}
+	for _, v := range names {
+		if state[v] != 0 {
+			continue
+		}
+		if err := visit(v); err != nil {
+			return nil, nil, err
+		}
+	}
+	return rst, cyclic, nil
+}
@@ -0,0 +1,183 @@
+package orange
+
+import (
+	"testing"
+)
+
+type author struct {
+	ID   int64
+	Name string
+}
+
+type article struct {
+	ID       int64
+	AuthorID int64 `sql:"name:author_id;relation:author;on_delete:cascade"`
+	Title    string
+}
+
+type review struct {
+	ID      int64
+	Article int64 `sql:"relation:article(id);on_delete:set null;on_update:cascade"`
+	Parent  int64 `sql:"relation:review"`
+}
+
+func TestSQL_SortedModels(t *testing.T) {
+	db, err := Open("postgres", testDB.ps)
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = db.Register(&review{}, &article{}, &author{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	models, cyclic, err := db.sortedModels()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(cyclic) != 0 {
+		t.Errorf("expected no cyclic relations got %v", cyclic)
+	}
+	expect := []string{"author", "article", "review"}
+	if len(models) != len(expect) {
+		t.Fatalf("expected %d models got %d", len(expect), len(models))
+	}
+	for k, v := range models {
+		if v.Name() != expect[k] {
+			t.Errorf("expected %s got %s", expect[k], v.Name())
+		}
+	}
+
+	p := &postgresql{}
+	sample := []struct {
+		model  interface{}
+		expect string
+	}{
+		{&article{}, "CREATE TABLE IF NOT EXISTS article (id bigserial,author_id bigint,title text,PRIMARY KEY (id)," +
+			"FOREIGN KEY (author_id) REFERENCES author (id) ON DELETE CASCADE);"},
+		{&review{}, "CREATE TABLE IF NOT EXISTS review (id bigserial,article bigint,parent bigint,PRIMARY KEY (id)," +
+			"FOREIGN KEY (article) REFERENCES article (id) ON DELETE SET NULL ON UPDATE CASCADE," +
+			"FOREIGN KEY (parent) REFERENCES review (id));"},
+	}
+	for _, v := range sample {
+		tab, err := loadTable(v.model)
+		if err != nil {
+			t.Fatal(err)
+		}
+		tab, err = db.resolve(tab)
+		if err != nil {
+			t.Fatal(err)
+		}
+		create, err := p.Create(tab)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if create != v.expect {
+			t.Errorf("expected %s got %s", v.expect, create)
+		}
+	}
+}
+
+type chicken struct {
+	ID  int64
+	Egg int64 `sql:"relation:egg"`
+}
+
+type egg struct {
+	ID      int64
+	Chicken int64 `sql:"relation:chicken"`
+}
+
+func TestSQL_SortedModelsCycle(t *testing.T) {
+	db, err := Open("postgres", testDB.ps)
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = db.Register(&chicken{}, &egg{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	dry := db.DryRun()
+	err = dry.CreateTables()
+	if err != nil {
+		t.Fatal(err)
+	}
+	expect := []string{
+		"CREATE TABLE IF NOT EXISTS egg (id bigserial,chicken bigint,PRIMARY KEY (id));",
+		"CREATE TABLE IF NOT EXISTS chicken (id bigserial,egg bigint,PRIMARY KEY (id),FOREIGN KEY (egg) REFERENCES egg (id));",
+		"ALTER TABLE egg ADD CONSTRAINT egg_chicken_fkey FOREIGN KEY (chicken) REFERENCES chicken (id);",
+	}
+	got := dry.Statements()
+	if len(got) != len(expect) {
+		t.Fatalf("expected %d statements got %d: %v", len(expect), len(got), got)
+	}
+	for k, v := range expect {
+		if got[k].Query != v {
+			t.Errorf("expected %s got %s", v, got[k].Query)
+		}
+	}
+
+	// unregistered models can not be referenced
+	db, err = Open("postgres", testDB.ps)
+	if err != nil {
+		t.Fatal(err)
+	}
+	_ = db.Register(&article{})
+	_, _, err = db.sortedModels()
+	if err == nil {
+		t.Error("expected an error")
+	}
+}
+
+func TestParseRelation(t *testing.T) {
+	sample := []struct {
+		rel, model, column string
+	}{
+		{"User", "User", ""},
+		{"User(id)", "User", "id"},
+		{" User ( code ) ", "User", "code"},
+	}
+	for _, v := range sample {
+		model, column := parseRelation(v.rel)
+		if model != v.model || column != v.column {
+			t.Errorf("expected %s %s got %s %s", v.model, v.column, model, column)
+		}
+	}
+}
+
+type note struct {
+	ID       int64
+	AuthorID int64  `sql:"name:author_id;relation:author;index"`
+	Slug     string `sql:"unique_index:idx_note_slug"`
+}
+
+func TestSQL_ResolveIndexes(t *testing.T) {
+	db, err := Open("postgres", testDB.ps)
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = db.Register(&author{}, &note{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	tab, err := loadTable(&note{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	tab, err = db.resolve(tab)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(foreignKeys(tab)) != 1 {
+		t.Errorf("expected 1 foreign key got %d", len(foreignKeys(tab)))
+	}
+	indexes := tableIndexes(tab)
+	expect := []string{"idx_note_author_id", "idx_note_slug"}
+	if len(indexes) != len(expect) {
+		t.Fatalf("expected %d indexes got %d", len(expect), len(indexes))
+	}
+	for k, v := range indexes {
+		if v.Name != expect[k] {
+			t.Errorf("expected %s got %s", expect[k], v.Name)
+		}
+	}
+}
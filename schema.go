@@ -0,0 +1,91 @@
+package orange
+
+import (
+	"bytes"
+	"strings"
+)
+
+// Inspect returns the tables of the live database with their columns, indexes
+// and foreign keys.
+func (s *SQL) Inspect() (*Schema, error) {
+	return s.adopter.Inspect(s)
+}
+
+// SchemaSQL returns the script that creates the tables, indexes and constraints
+// of the registered models with the current adopter. Tables are created after
+// the tables they reference, and every statement is on its own line.
+func (s *SQL) SchemaSQL() (string, error) {
+	if !s.hasModels() {
+		return "", ErrNoModels
+	}
+	// the script is annotated with neither the comment tags of s nor those
+	// of its context.
+	dry := s.DryRun().DisableComments()
+	err := dry.CreateTables()
+	if err != nil {
+		return "", err
+	}
+	buf := &bytes.Buffer{}
+	for _, v := range dry.Statements() {
+		_, _ = buf.WriteString(strings.TrimSpace(v.Query) + "\n")
+	}
+	return buf.String(), nil
+}
+
+// Schema is the structure of a live database as reported by the adopter.
+type Schema struct {
+	Tables []*TableSchema
+}
+
+// Table returns the table named name, nil is returned if there is no such
+// table.
+func (s *Schema) Table(name string) *TableSchema {
+	for _, v := range s.Tables {
+		if v.Name == name {
+			return v
+		}
+	}
+	return nil
+}
+
+// TableSchema is the structure of a live database table.
+type TableSchema struct {
+	Name        string
+	Columns     []*ColumnSchema
+	PrimaryKey  []string
+	Indexes     []Index
+	ForeignKeys []ForeignKey
+}
+
+// Column returns the column named name, nil is returned if there is no such
+// column.
+func (t *TableSchema) Column(name string) *ColumnSchema {
+	for _, v := range t.Columns {
+		if v.Name == name {
+			return v
+		}
+	}
+	return nil
+}
+
+// Index returns the index named name, nil is returned if there is no such
+// index.
+func (t *TableSchema) Index(name string) *Index {
+	for k := range t.Indexes {
+		if t.Indexes[k].Name == name {
+			return &t.Indexes[k]
+		}
+	}
+	return nil
+}
+
+// ColumnSchema is the structure of a live database column.
+type ColumnSchema struct {
+	Name     string
+	Type     string
+	Nullable bool
+
+	// Default is the default expression of the column, it is empty when the
+	// column has no default.
+	Default string
+}
@@ -0,0 +1,63 @@
+package orange
+
+import (
+	"context"
+	"testing"
+)
+
+func TestSQL_SchemaSQL(t *testing.T) {
+	db, err := Open("postgres", testDB.ps)
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, err = db.SchemaSQL()
+	if err != ErrNoModels {
+		t.Errorf("expected %v got %v", ErrNoModels, err)
+	}
+	_, err = db.Verify()
+	if err != ErrNoModels {
+		t.Errorf("expected %v got %v", ErrNoModels, err)
+	}
+	db.EnableComments(false)
+	err = db.Register(&article{}, &author{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	script, err := db.SchemaSQL()
+	if err != nil {
+		t.Fatal(err)
+	}
+	expect := "CREATE TABLE IF NOT EXISTS author (id bigserial,name text,PRIMARY KEY (id));\n" +
+		"CREATE TABLE IF NOT EXISTS article (id bigserial,author_id bigint,title text,PRIMARY KEY (id)," +
+		"FOREIGN KEY (author_id) REFERENCES author (id) ON DELETE CASCADE);\n"
+	if script != expect {
+		t.Errorf("expected %s got %s", expect, script)
+	}
+	if db.Statements() != nil {
+		t.Error("expected the statements not to be collected by db")
+	}
+
+	// indexes of related tables are created and comments from the context
+	// are left out
+	db, err = Open("postgres", testDB.ps)
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = db.Register(&author{}, &note{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	db = db.WithContext(WithComment(context.Background(), "route", "/schema")).Comment("app", "billing")
+	script, err = db.SchemaSQL()
+	if err != nil {
+		t.Fatal(err)
+	}
+	expect = "CREATE TABLE IF NOT EXISTS author (id bigserial,name text,PRIMARY KEY (id));\n" +
+		"CREATE TABLE IF NOT EXISTS note (id bigserial,author_id bigint,slug text,PRIMARY KEY (id)," +
+		"FOREIGN KEY (author_id) REFERENCES author (id));\n" +
+		"CREATE INDEX IF NOT EXISTS idx_note_author_id ON note (author_id);\n" +
+		"CREATE UNIQUE INDEX IF NOT EXISTS idx_note_slug ON note (slug);\n"
+	if script != expect {
+		t.Errorf("expected %s got %s", expect, script)
+	}
+}
@@ -0,0 +1,136 @@
+package orange
+
+import (
+	"fmt"
+	"reflect"
+	"runtime"
+	"strings"
+	"time"
+)
+
+// SlowQuery holds details about a statement whose execution took longer than
+// the threshold set with *SQL.SlowQuery.
+type SlowQuery struct {
+	Query    string
+	Args     []interface{}
+	Duration time.Duration
+
+	//Caller is the file:line of the code outside orange that triggered the
+	//query, test files of orange count as code outside orange.
+	Caller string
+
+	//Explain is the query plan as reported by the database. It is only set
+	//when explaining is enabled with *SQL.ExplainSlowQueries.
+	Explain string
+}
+
+// SlowQueryFunc is a function that is called with every slow query.
+type SlowQueryFunc func(*SlowQuery)
+
+type slowQuery struct {
+	threshold time.Duration
+	fn        SlowQueryFunc
+}
+
+// pkgPath is the import path of this package, it is used to skip orange's own
+// frames when looking for the call site of a query.
+var pkgPath = reflect.TypeOf(SQL{}).PkgPath()
+
+// SlowQuery sets threshold as the maximum time a statement is allowed to take,
+// fn will be called with details of every statement that takes longer than
+// threshold. Setting threshold to zero disables slow query reporting.
+func (s *SQL) SlowQuery(threshold time.Duration, fn SlowQueryFunc) *SQL {
+	if threshold <= 0 || fn == nil {
+		s.slow = nil
+		return s
+	}
+	s.slow = &slowQuery{threshold: threshold, fn: fn}
+	return s
+}
+
+// ExplainSlowQueries enables capturing the query plan of slow queries. The plan
+// is obtained by executing the sql returned by the adopter's Explain method
+// with the same arguments as the slow query. It can be called before or after
+// SlowQuery.
+func (s *SQL) ExplainSlowQueries(explain bool) *SQL {
+	s.explainSlow = explain
+	return s
+}
+
+// observe reports query as slow if it took longer than the configured
+// threshold.
+func (s *SQL) observe(query string, args []interface{}, d time.Duration) {
+	if s.slow == nil || d < s.slow.threshold {
+		return
+	}
+	q := &SlowQuery{
+		Query:    query,
+		Args:     args,
+		Duration: d,
+		Caller:   callSite(),
+	}
+	if s.explainSlow {
+		q.Explain = s.explain(query, args...)
+	}
+	s.slow.fn(q)
+}
+
+// explain returns the query plan for query, an empty string is returned if the
+// plan can not be obtained.
+func (s *SQL) explain(query string, args ...interface{}) string {
+	if !isExplainable(query) {
+		return ""
+	}
+	rows, err := s.conn().QueryContext(s.context(), s.adopter.Explain(query), args...)
+	if err != nil {
+		return ""
+	}
+	defer func() { _ = rows.Close() }()
+	var plan []string
+	for rows.Next() {
+		var line string
+		if err := rows.Scan(&line); err != nil {
+			return ""
+		}
+		plan = append(plan, line)
+	}
+	return strings.Join(plan, "\n")
+}
+
+// isExplainable returns true if query is a statement that the database can
+// explain. A comment placed before the statement is skipped.
+func isExplainable(query string) bool {
+	q := strings.ToUpper(stripComment(query))
+	for _, v := range []string{"SELECT", "INSERT", "UPDATE", "DELETE", "WITH"} {
+		if strings.HasPrefix(q, v) {
+			return true
+		}
+	}
+	return false
+}
+
+// callSite returns file:line of the first caller which is not part of orange.
+func callSite() string {
+	pc := make([]uintptr, 32)
+	n := runtime.Callers(2, pc)
+	frames := runtime.CallersFrames(pc[:n])
+	for {
+		frame, more := frames.Next()
+		if !ownFrame(frame) {
+			return fmt.Sprintf("%s:%d", frame.File, frame.Line)
+		}
+		if !more {
+			break
+		}
+	}
+	return ""
+}
+
+// ownFrame returns true if frame is in the sources of orange. Frames in test
+// files, of orange or any other package, are never orange's own.
+func ownFrame(frame runtime.Frame) bool {
+	if strings.HasSuffix(frame.File, "_test.go") {
+		return false
+	}
+	return strings.HasPrefix(frame.Function, pkgPath+".")
+}
@@ -0,0 +1,99 @@
+package orange
+
+import (
+	"runtime"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestSQL_SlowQuery(t *testing.T) {
+	db, err := Open("postgres", testDB.ps)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var reported []*SlowQuery
+	db.SlowQuery(time.Second, func(q *SlowQuery) {
+		reported = append(reported, q)
+	})
+	db.observe("SELECT 1;", nil, time.Millisecond)
+	if len(reported) != 0 {
+		t.Errorf("expected no slow queries got %d", len(reported))
+	}
+	db.observe("SELECT 1;", []interface{}{1}, 2*time.Second)
+	if len(reported) != 1 {
+		t.Fatalf("expected 1 slow query got %d", len(reported))
+	}
+	q := reported[0]
+	if q.Query != "SELECT 1;" {
+		t.Errorf("expected %s got %s", "SELECT 1;", q.Query)
+	}
+	if !strings.Contains(q.Caller, "slow_test.go") {
+		t.Errorf("expected caller to be slow_test.go got %s", q.Caller)
+	}
+
+	// zero threshold disables reporting
+	db.SlowQuery(0, nil)
+	db.observe("SELECT 1;", nil, time.Hour)
+	if len(reported) != 1 {
+		t.Errorf("expected 1 slow query got %d", len(reported))
+	}
+}
+
+func TestSQL_ExplainSlowQueries(t *testing.T) {
+	db, err := Open("postgres", testDB.ps)
+	if err != nil {
+		t.Fatal(err)
+	}
+	db.SlowQuery(time.Second, func(*SlowQuery) {})
+	explained := db.Copy().ExplainSlowQueries(true)
+	if db.explainSlow {
+		t.Error("expected explaining a copy to leave the original alone")
+	}
+	if !explained.explainSlow {
+		t.Error("expected explaining to be enabled")
+	}
+
+	// the order of ExplainSlowQueries and SlowQuery does not matter
+	ordered := db.Copy().ExplainSlowQueries(true).SlowQuery(time.Second, func(*SlowQuery) {})
+	if !ordered.explainSlow {
+		t.Error("expected explaining to be enabled before SlowQuery")
+	}
+}
+
+func TestOwnFrame(t *testing.T) {
+	sample := []struct {
+		frame  runtime.Frame
+		expect bool
+	}{
+		{runtime.Frame{Function: pkgPath + ".(*SQL).exec", File: "/src/orange/hook.go"}, true},
+		{runtime.Frame{Function: pkgPath + ".TestSQL_Create", File: "/src/orange/sql_test.go"}, false},
+		{runtime.Frame{Function: pkgPath + "/cli.Run", File: "/src/orange/cli/cli.go"}, false},
+		{runtime.Frame{Function: pkgPath + "_test.TestExample", File: "/src/orange/example_test.go"}, false},
+		{runtime.Frame{Function: "main.main", File: "/src/app/main.go"}, false},
+	}
+	for _, v := range sample {
+		if ownFrame(v.frame) != v.expect {
+			t.Errorf("expected %v for %s", v.expect, v.frame.Function)
+		}
+	}
+}
+
+func TestIsExplainable(t *testing.T) {
+	sample := []struct {
+		query  string
+		expect bool
+	}{
+		{"SELECT * FROM golangster;", true},
+		{" update golangster SET name='x'", true},
+		{"CREATE TABLE IF NOT EXISTS golangster (id serial);", false},
+		{"DROP TABLE IF EXISTS golangster", false},
+		{"/* app=billing */ SELECT * FROM golangster;", true},
+		{"/* app=billing */ DROP TABLE golangster;", false},
+	}
+	for _, v := range sample {
+		if isExplainable(v.query) != v.expect {
+			t.Errorf("expected %v for %s", v.expect, v.query)
+		}
+	}
+}
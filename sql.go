@@ -2,7 +2,9 @@ package orange
 
 import (
 	"bytes"
+	"context"
 	"database/sql"
+	"database/sql/driver"
 	"errors"
 	"fmt"
 	"html"
@@ -27,9 +29,23 @@ type SQL struct {
 	clause  struct {
 		where, limit, offset, order, count, dbSelect *clause
 	}
-	db      *sql.DB
-	verbose bool
-	isDone  bool // true when the current query has already been executed.
+	db          *sql.DB
+	verbose     bool
+	isDone      bool // true when the current query has already been executed.
+	slow        *slowQuery
+	explainSlow bool // capture the query plan of slow queries.
+	hooks       []Hook
+	table       string // the table that the composed query selects from
+	model       string // the name of the model that the composed query selects
+	ctx         context.Context
+	comments    *comments
+	tx          *sql.Tx
+	session     *sql.Conn // the connection holding a lock, see withLock.
+	callbacks   *Callbacks
+	migrations  *migrations
+	lockTimeout time.Duration
+	dryRun      *dryRun
+	err         error // the first error of composing the current query.
 }
 
 func newSQL(dbAdopter Adopter, dbConnection string) (*SQL, error) {
@@ -38,10 +54,12 @@ func newSQL(dbAdopter Adopter, dbConnection string) (*SQL, error) {
 		return nil, err
 	}
 	return &SQL{
-		models:  make(map[string]Table),
-		adopter: dbAdopter,
-		loader:  loadTable,
-		db:      db,
+		models:     make(map[string]Table),
+		adopter:    dbAdopter,
+		loader:     loadTable,
+		db:         db,
+		callbacks:  newCallbacks(),
+		migrations: &migrations{},
 	}, nil
 }
 
@@ -106,7 +124,7 @@ func (s *SQL) DropTable(model interface{}) error {
 		if err != nil {
 			return err
 		}
-		_, err = s.Exec(query)
+		_, err = s.exec(&statement{op: OpMigrate, table: t.Name(), query: query})
 		if err != nil {
 			return err
 		}
@@ -115,6 +133,13 @@ func (s *SQL) DropTable(model interface{}) error {
 	return errors.New("the table is not registered yet ")
 }
 
+// hasModels returns true if at least one model is registered.
+func (s *SQL) hasModels() bool {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return len(s.models) > 0
+}
+
 //getModel returns the table registered by name, returns nil if the table was
 //not yet registered.
 func (s *SQL) getModel(name string) Table {
@@ -123,16 +148,21 @@ func (s *SQL) getModel(name string) Table {
 	return s.models[name]
 }
 
-//Automigrate creates the database tables if they don't exist
+//Automigrate creates the database tables and their indexes if they don't exist.
+//Tables are created after the tables they reference. Columns and indexes which
+//are missing from existing tables are added, see AutomigrateReport for details.
+//
+//Differences which would alter or drop existing data, like extra columns or
+//columns of another type, are skipped without failing. They are returned by
+//AutomigrateReport and Verify, and printed in verbose mode.
 func (s *SQL) Automigrate() error {
-	for _, m := range s.models {
-		query, err := s.adopter.Create(m)
-		if err != nil {
-			return err
-		}
-		_, err = s.Exec(query)
-		if err != nil {
-			return err
+	skipped, err := s.AutomigrateReport()
+	if err != nil {
+		return err
+	}
+	if s.verbose {
+		for _, v := range skipped {
+			fmt.Println("skipped", v)
 		}
 	}
 	return nil
@@ -142,9 +172,22 @@ func (s *SQL) Automigrate() error {
 //avoid messing up the scope.
 func (s *SQL) Copy() *SQL {
 	return &SQL{
-		db:      s.db,
-		models:  s.models,
-		adopter: s.adopter,
+		db:          s.db,
+		models:      s.models,
+		adopter:     s.adopter,
+		loader:      s.loader,
+		verbose:     s.verbose,
+		slow:        s.slow,
+		explainSlow: s.explainSlow,
+		hooks:       s.hooks,
+		ctx:         s.ctx,
+		comments:    s.comments.copy(),
+		tx:          s.tx,
+		session:     s.session,
+		callbacks:   s.callbacks,
+		migrations:  s.migrations,
+		lockTimeout: s.lockTimeout,
+		dryRun:      s.dryRun,
 	}
 }
 
@@ -176,16 +219,22 @@ func (s *SQL) Where(value interface{}, args ...interface{}) *SQL {
 	case reflect.Struct:
 		t, err := loadTable(value)
 		if err != nil {
-			//TODO handle?
-			return dup
+			return dup.queryError(err)
 		}
 		cols, vals, err := Values(t, value)
 		if err != nil {
-			return dup
+			return dup.queryError(err)
 		}
 		var keyVal string
 		for k, v := range cols {
-			keyVal = keyVal + fmt.Sprintf(" %s=%s", v, s.quote(vals[k]))
+			if k > 0 {
+				keyVal = keyVal + " AND"
+			}
+			val, err := s.quote(vals[k])
+			if err != nil {
+				return dup.queryError(fmt.Errorf("column %s: %v", v, err))
+			}
+			keyVal = keyVal + fmt.Sprintf(" %s=%s", v, val)
 		}
 		dup.clause.where = &clause{condition: keyVal}
 		return dup
@@ -193,6 +242,16 @@ func (s *SQL) Where(value interface{}, args ...interface{}) *SQL {
 	return dup
 }
 
+// queryError records err as the error of the composed query. BuildQuery and
+// Bind return it, instead of running the query without the failed condition.
+func (s *SQL) queryError(err error) *SQL {
+	dup := s.CopyQuery()
+	if dup.err == nil {
+		dup.err = err
+	}
+	return dup
+}
+
 //Values returns the fields that are present in the table t which have values
 //set in model v.
 // THis tries to breakdown the mapping of table collum names with their
@@ -206,7 +265,7 @@ func (s *SQL) Where(value interface{}, args ...interface{}) *SQL {
 // After loading a table representation of foo, you can get the column names
 // that have been assigned values like this
 //	cols,vals,err:=Values(fooTable,&foo{ID: 1})
-//	// cols will be []string{"ID"}
+//	// cols will be []string{"id"}
 //	// vals will be []interface{}{1}
 func Values(t Table, v interface{}) (cols []string, vals []interface{}, err error) {
 	f, err := t.Fields()
@@ -224,18 +283,8 @@ func Values(t Table, v interface{}) (cols []string, vals []interface{}, err erro
 			if reflect.DeepEqual(zero.Interface(), fv.Interface()) {
 				continue
 			}
-			colName := field.Name()
-			tags, _ := field.Flags()
-			if tags != nil {
-				for _, tag := range tags {
-					if tag.Name() == "field_name" {
-						colName = tag.Value()
-						break
-					}
-				}
-			}
-			cols = append(cols, colName)
-			vals = append(vals, fv.Interface())
+			cols = append(cols, field.ColumnName())
+			vals = append(vals, fieldValue(field, fv))
 		}
 	}
 	return
@@ -306,6 +355,8 @@ func (s *SQL) Select(query interface{}, args ...interface{}) *SQL {
 		q := "* FROM " + t.Name()
 		c := &clause{condition: q}
 		dup.clause.dbSelect = c
+		dup.table = t.Name()
+		dup.model = val.Type().Name()
 		return dup
 	case reflect.Ptr:
 		val = val.Elem()
@@ -318,6 +369,8 @@ func (s *SQL) Select(query interface{}, args ...interface{}) *SQL {
 			q := "* FROM " + t.Name()
 			c := &clause{condition: q}
 			dup.clause.dbSelect = c
+			dup.table = t.Name()
+			dup.model = val.Type().Name()
 			return dup
 		}
 	}
@@ -326,6 +379,9 @@ func (s *SQL) Select(query interface{}, args ...interface{}) *SQL {
 
 //BuildQuery returns the sql query that will be executed
 func (s *SQL) BuildQuery() (string, []interface{}, error) {
+	if s.err != nil {
+		return "", nil, s.err
+	}
 	buf := &bytes.Buffer{}
 	var args []interface{}
 	if s.clause.dbSelect != nil {
@@ -339,32 +395,29 @@ func (s *SQL) BuildQuery() (string, []interface{}, error) {
 			}
 		}
 		_, _ = buf.WriteString(selectCond)
-		if s.clause.dbSelect.args != nil {
-			args = append(args, s.clause.dbSelect.args)
-		}
+		args = append(args, s.clause.dbSelect.args...)
 	}
 	if s.clause.where != nil {
-		_, _ = buf.WriteString(" WHERE" + s.clause.where.condition)
-		if s.clause.dbSelect != nil && s.clause.dbSelect.args != nil {
-			args = append(args, s.clause.where.args)
-		}
+		_, _ = buf.WriteString(" WHERE " + strings.TrimSpace(s.clause.where.condition))
+		args = append(args, s.clause.where.args...)
 	}
 	if s.clause.offset != nil {
 		_, _ = buf.WriteString("OFFSET " + s.clause.offset.condition)
-		if s.clause.dbSelect.args != nil {
-			args = append(args, s.clause.offset.args)
-		}
+		args = append(args, s.clause.offset.args...)
 	}
 	if s.clause.limit != nil {
 		_, _ = buf.WriteString("LIMIT" + s.clause.limit.condition)
-		if s.clause.dbSelect.args != nil {
-			args = append(args, s.clause.limit.args)
-		}
+		args = append(args, s.clause.limit.args...)
 	}
 	_, _ = buf.WriteString(";")
 	if s.verbose {
 		fmt.Println(buf.String())
 	}
+	for k, v := range args {
+		// the drivers don't know the types stored in the formats of the
+		// adopter e.g slices stored in arrays.
+		args[k] = s.convertValue(v)
+	}
 	return buf.String(), cleanArgs(args...), nil
 }
 
@@ -408,7 +461,10 @@ type valScanner interface {
 	Scan(dest ...interface{}) error
 }
 
-func (s *SQL) scanStruct(scanner valScanner, model interface{}) error {
+// scanStruct scans the current row into model. The columns are matched with
+// the fields of model by the column name, columns without a matching field are
+// discarded.
+func (s *SQL) scanStruct(scanner valScanner, columns []string, model interface{}) error {
 	val := reflect.ValueOf(model)
 	if val.Kind() != reflect.Ptr {
 		return errors.New("can not assign to model")
@@ -417,14 +473,29 @@ func (s *SQL) scanStruct(scanner valScanner, model interface{}) error {
 	if err != nil {
 		return err
 	}
-	var result []interface{}
 	fields, err := t.Fields()
 	if err != nil {
 		return err
 	}
-
+	byColumn := make(map[string]Field)
 	for _, v := range fields {
-		result = append(result, reflect.New(v.Type()))
+		byColumn[strings.ToLower(v.ColumnName())] = v
+	}
+	result := make([]interface{}, len(columns))
+	values := make([]interface{}, len(columns))
+	for k, v := range columns {
+		f, ok := byColumn[strings.ToLower(v)]
+		if !ok {
+			result[k] = new(interface{})
+			continue
+		}
+		values[k] = reflect.New(f.Type()).Interface()
+		result[k] = values[k]
+		if isJSON(f) {
+			result[k] = &jsonScanner{dest: values[k]}
+		} else if sc := s.adopter.Scanner(values[k]); sc != nil {
+			result[k] = sc
+		}
 	}
 	err = scanner.Scan(result...)
 	if err != nil {
@@ -433,10 +504,12 @@ func (s *SQL) scanStruct(scanner valScanner, model interface{}) error {
 
 	// we use the actual value now not the address
 	val = val.Elem()
-	for k, v := range fields {
-		f := val.FieldByName(v.Name())
-		fVal := reflect.ValueOf(result[k])
-		f.Set(fVal.Elem())
+	for k, v := range columns {
+		f, ok := byColumn[strings.ToLower(v)]
+		if !ok {
+			continue
+		}
+		val.FieldByName(f.Name()).Set(reflect.ValueOf(values[k]).Elem())
 	}
 	return nil
 }
@@ -444,18 +517,18 @@ func (s *SQL) scanStruct(scanner valScanner, model interface{}) error {
 //Query retriews matching rows . This wraps the sql.Query and no further
 //no further processing is done.
 func (s *SQL) Query(query string, args ...interface{}) (*sql.Rows, error) {
-	return s.db.Query(query, args...)
+	return s.query(newStatement(query, args))
 }
 
 //QueryRow QueryRow returnes a single matched row. This wraps sql.QueryRow no
 //further processing is done.
 func (s *SQL) QueryRow(query string, args ...interface{}) *sql.Row {
-	return s.db.QueryRow(query, args...)
+	return s.queryRow(newStatement(query, args))
 }
 
 // Exec executes the query.
 func (s *SQL) Exec(query string, args ...interface{}) (sql.Result, error) {
-	return s.db.Exec(query, args...)
+	return s.exec(newStatement(query, args))
 }
 
 //CurrentDatabase returns the name of the database in which the queries are
@@ -464,6 +537,26 @@ func (s *SQL) CurrentDatabase() string {
 	return s.adopter.Database(s)
 }
 
+//CreateDatabase creates the database name on the server that s is connected to.
+func (s *SQL) CreateDatabase(name string) error {
+	query, err := s.adopter.CreateDatabase(name)
+	if err != nil {
+		return err
+	}
+	_, err = s.exec(&statement{op: OpMigrate, query: query})
+	return err
+}
+
+//DropDatabase drops the database name if it exists.
+func (s *SQL) DropDatabase(name string) error {
+	query, err := s.adopter.DropDatabase(name)
+	if err != nil {
+		return err
+	}
+	_, err = s.exec(&statement{op: OpMigrate, query: query})
+	return err
+}
+
 //Bind executes the query and scans results into value. If there is any error it
 //will be returned.
 //
@@ -502,32 +595,40 @@ func (s *SQL) Bind(value interface{}, args ...interface{}) error {
 		if err != nil {
 			return err
 		}
-		fields, err := t.Fields()
-		for _, v := range fields {
-			scanArgs = append(scanArgs, reflect.New(v.Type()).Interface())
-		}
-		query, qArgs, err := s.BuildQuery()
+		scope := &Scope{SQL: s, Operation: OpSelect, Model: value, Table: t,
+			build: s.BuildQuery}
+		err = scope.Rebuild()
 		if err != nil {
 			return err
 		}
-		var row *sql.Row
-		switch len(qArgs) {
-		case 0:
-			row = s.QueryRow(query)
-		default:
-			row = s.QueryRow(query, qArgs...)
+		err = s.callbacks.Query().runBefore(scope)
+		if err != nil {
+			return err
 		}
-		err = row.Scan(scanArgs...)
+		rows, err := s.query(&statement{op: OpSelect, table: s.table, model: s.model, query: scope.Query, args: scope.Args})
 		if err != nil {
 			return err
 		}
-		for k, v := range scanArgs {
-			sField, ok := actualVal.Type().FieldByName(fields[k].Name())
-			if ok {
-				aField := actualVal.FieldByName(sField.Name)
-				aField.Set(reflect.ValueOf(v).Elem())
+		defer func() { _ = rows.Close() }()
+		if !rows.Next() {
+			if err = rows.Err(); err != nil {
+				return err
 			}
+			return sql.ErrNoRows
 		}
+		columns, err := rows.Columns()
+		if err != nil {
+			return err
+		}
+		err = s.scanStruct(rows, columns, value)
+		if err != nil {
+			return err
+		}
+		err = s.afterHook(value, OpSelect)
+		if err != nil {
+			return err
+		}
+		return s.callbacks.Query().runAfter(scope)
 	default:
 		scanArgs = append(scanArgs, value)
 		if len(args) > 0 {
@@ -537,26 +638,55 @@ func (s *SQL) Bind(value interface{}, args ...interface{}) error {
 		if err != nil {
 			return err
 		}
-		var row *sql.Row
-		switch len(qArgs) {
-		case 0:
-			row = s.QueryRow(query)
-		default:
-			row = s.QueryRow(query, qArgs...)
-		}
+		row := s.queryRow(&statement{op: OpSelect, table: s.table, model: s.model, query: query, args: qArgs})
 		return row.Scan(scanArgs...)
 	}
-	return nil
 }
 
 //Create creates a new record into the database
 func (s *SQL) Create(model interface{}) error {
-	query, err := s.creare(model)
+	return s.save(OpCreate, model, func(m interface{}) (string, []interface{}, error) {
+		query, err := s.creare(m)
+		return query, nil, err
+	})
+}
+
+// save executes the statement returned by build for model. The model hooks and
+// the registered callbacks for op are called before and after the statement is
+// executed. Unless s is already in a transaction all of them run in a new
+// transaction, so that an error returned by an After hook or callback rolls
+// back the statement.
+func (s *SQL) save(op Operation, model interface{}, build func(interface{}) (string, []interface{}, error)) error {
+	t, err := s.loader(model)
 	if err != nil {
 		return err
 	}
-	_, err = s.Exec(query)
-	return err
+	return s.inTx(func(tx *SQL) error {
+		err := tx.beforeHook(model, op)
+		if err != nil {
+			return err
+		}
+		scope := &Scope{SQL: tx, Operation: op, Model: model, Table: t,
+			build: func() (string, []interface{}, error) { return build(model) }}
+		err = scope.Rebuild()
+		if err != nil {
+			return err
+		}
+		cbs := tx.callbacks.processor(op)
+		err = cbs.runBefore(scope)
+		if err != nil {
+			return err
+		}
+		_, err = tx.exec(&statement{op: op, table: t.Name(), model: modelName(model), query: scope.Query, args: scope.Args})
+		if err != nil {
+			return err
+		}
+		err = tx.afterHook(model, op)
+		if err != nil {
+			return err
+		}
+		return cbs.runAfter(scope)
+	})
 }
 
 func (s *SQL) creare(model interface{}) (string, error) {
@@ -583,11 +713,15 @@ func (s *SQL) creare(model interface{}) (string, error) {
 	_, _ = buf.WriteString(" VALUES (")
 
 	for k, v := range vals {
+		val, err := s.quote(v)
+		if err != nil {
+			return "", fmt.Errorf("column %s: %v", cols[k], err)
+		}
 		if k == 0 {
-			_, _ = buf.WriteString(s.quote(v))
+			_, _ = buf.WriteString(val)
 			continue
 		}
-		_, _ = buf.WriteString(fmt.Sprintf(", %v", s.quote(v)))
+		_, _ = buf.WriteString(", " + val)
 	}
 	_, _ = buf.WriteString(");")
 	return buf.String(), nil
@@ -609,59 +743,240 @@ func createValues(t Table, v interface{}) (cols []string, vals []interface{}, er
 			zero := reflect.Zero(fv.Type())
 			colName := field.ColumnName()
 			if reflect.DeepEqual(zero.Interface(), fv.Interface()) {
-				if colName == "created_at" || colName == "updated_at" {
+				switch {
+				case colName == "created_at" || colName == "updated_at":
 					cols = append(cols, colName)
 					vals = append(vals, time.Now().Format(time.RFC3339))
+				case nullableType(fv.Type()) && !hasFlag(field, specialTags.defaultValue):
+					cols = append(cols, colName)
+					vals = append(vals, nil)
+				case valuerKind(fv.Type()) && !hasFlag(field, specialTags.defaultValue):
+					// the zero value of a driver.Valuer is written by its
+					// Value method.
+					cols = append(cols, colName)
+					vals = append(vals, fieldValue(field, fv))
 				}
 				continue
 			}
 			cols = append(cols, colName)
-			vals = append(vals, fv.Interface())
+			vals = append(vals, fieldValue(field, fv))
 		}
 	}
 	return
 }
 
-//Update updates a model values into the database
-func (s *SQL) Update(model interface{}) error {
-	query, err := s.update(model)
+//updateValues returns the values for updating the record of v. These are the
+//values of the fields which are set, and the values of the columns listed in
+//columns even when they are zero. Nil pointers and invalid sql.Null values of
+//the listed columns are written as NULL.
+func updateValues(t Table, v interface{}, columns ...string) (cols []string, vals []interface{}, err error) {
+	f, err := t.Fields()
 	if err != nil {
-		return err
+		return
 	}
-	_, err = s.Exec(query)
-	return err
+	listed := make(map[string]bool)
+	for _, c := range columns {
+		listed[c] = true
+	}
+	value := reflect.ValueOf(v)
+	if value.Kind() == reflect.Ptr {
+		value = value.Elem()
+	}
+	for _, field := range f {
+		fv := value.FieldByName(field.Name())
+		if !fv.IsValid() {
+			continue
+		}
+		if !listed[field.ColumnName()] && reflect.DeepEqual(reflect.Zero(fv.Type()).Interface(), fv.Interface()) {
+			continue
+		}
+		delete(listed, field.ColumnName())
+		cols = append(cols, field.ColumnName())
+		vals = append(vals, fieldValue(field, fv))
+	}
+	for c := range listed {
+		return nil, nil, fmt.Errorf("table %s has no column %s", t.Name(), c)
+	}
+	return
+}
+
+//Update updates a model values into the database. Only the fields which are set
+//are written, use UpdateColumns for writing zero values and NULL.
+func (s *SQL) Update(model interface{}) error {
+	return s.UpdateColumns(model)
+}
+
+//UpdateColumns updates the record of model like Update, the columns are
+//written even when their fields are zero. This is how a column is set to NULL,
+//by listing it with a nil pointer or an invalid sql.Null value.
+//
+//	db.UpdateColumns(&User{ID: 1, Nick: nil}, "nick")
+//	// UPDATE user SET nick =NULL WHERE id=$1
+func (s *SQL) UpdateColumns(model interface{}, columns ...string) error {
+	return s.save(OpUpdate, model, func(m interface{}) (string, []interface{}, error) {
+		return s.update(m, columns...)
+	})
 }
 
-func (s *SQL) update(model interface{}) (string, error) {
+// update returns the statement updating the record of model, the values of the
+// primary key are the arguments of the statement.
+func (s *SQL) update(model interface{}, columns ...string) (string, []interface{}, error) {
 	t, err := s.loader(model)
 	if err != nil {
-		return "", err
+		return "", nil, err
 	}
-	cols, vals, err := Values(t, model)
+	where, keys, err := s.keyCondition(t, model)
 	if err != nil {
-		return "", err
+		return "", nil, err
+	}
+	cols, vals, err := updateValues(t, model, columns...)
+	if err != nil {
+		return "", nil, err
+	}
+	pk := make(map[string]bool)
+	for _, v := range primaryKeys(t) {
+		pk[v.ColumnName()] = true
 	}
-	var where string
 	var up string
 	for k, v := range cols {
-		if strings.ToLower(v) == "id" {
-			where = fmt.Sprintf(" %s=%v", v, s.quote(vals[k]))
+		if pk[v] {
 			continue
 		}
+		val, err := s.quote(vals[k])
+		if err != nil {
+			return "", nil, fmt.Errorf("column %s: %v", v, err)
+		}
 		if up == "" {
-			up = fmt.Sprintf("%s =%v", v, s.quote(vals[k]))
+			up = fmt.Sprintf("%s =%v", v, val)
 			continue
 		}
-		up = up + fmt.Sprintf(",%s =%v", v, s.quote(vals[k]))
+		up = up + fmt.Sprintf(",%s =%v", v, val)
 	}
-	return fmt.Sprintf("UPDATE %s SET %s WHERE %s", t.Name(), up, where), nil
+	return fmt.Sprintf("UPDATE %s SET %s WHERE %s", t.Name(), up, where), keys, nil
+}
+
+//Delete deletes the record of model from the database. The record is matched
+//by the primary key of the model.
+func (s *SQL) Delete(model interface{}) error {
+	return s.save(OpDelete, model, s.delete)
 }
 
-// quote add single quote to val if val is a string.
-func (s *SQL) quote(val interface{}) string {
-	typ := reflect.TypeOf(val)
-	if typ.Kind() == reflect.String {
-		return fmt.Sprintf("'%v'", val)
+// delete returns the statement deleting the record of model, the values of the
+// primary key are the arguments of the statement.
+func (s *SQL) delete(model interface{}) (string, []interface{}, error) {
+	t, err := s.loader(model)
+	if err != nil {
+		return "", nil, err
+	}
+	where, keys, err := s.keyCondition(t, model)
+	if err != nil {
+		return "", nil, err
+	}
+	return fmt.Sprintf("DELETE FROM %s WHERE %s", t.Name(), where), keys, nil
+}
+
+//FindByKey retrieves the record whose primary key is keys into model. The keys
+//are in the same order as the primary key fields of the model.
+func (s *SQL) FindByKey(model interface{}, keys ...interface{}) error {
+	t, err := s.loader(model)
+	if err != nil {
+		return err
+	}
+	fields := primaryKeys(t)
+	if len(fields) == 0 {
+		return fmt.Errorf("table %s has no primary key", t.Name())
+	}
+	if len(fields) != len(keys) {
+		return fmt.Errorf("table %s has %d primary key fields got %d keys", t.Name(), len(fields), len(keys))
+	}
+	return s.CopyQuery().Select(model).Where(s.keyPlaceholders(fields), keys...).Bind(model)
+}
+
+// keyPlaceholders returns the condition which matches the primary key fields,
+// the values of the keys are passed as arguments.
+func (s *SQL) keyPlaceholders(fields []Field) string {
+	var cond []string
+	for k, v := range fields {
+		cond = append(cond, fmt.Sprintf("%s=%s", v.ColumnName(), s.adopter.Quote(k+1)))
+	}
+	return strings.Join(cond, " AND ")
+}
+
+// keyCondition returns the condition which matches the primary key of model,
+// and the values of the primary key which are its arguments.
+func (s *SQL) keyCondition(t Table, model interface{}) (string, []interface{}, error) {
+	fields := primaryKeys(t)
+	if len(fields) == 0 {
+		return "", nil, fmt.Errorf("table %s has no primary key", t.Name())
+	}
+	value := reflect.ValueOf(model)
+	if value.Kind() == reflect.Ptr {
+		value = value.Elem()
+	}
+	var keys []interface{}
+	for _, v := range fields {
+		fv := value.FieldByName(v.Name())
+		if !fv.IsValid() || reflect.DeepEqual(reflect.Zero(fv.Type()).Interface(), fv.Interface()) {
+			return "", nil, fmt.Errorf("missing value for primary key %s", v.ColumnName())
+		}
+		keys = append(keys, s.convertValue(fieldValue(v, fv)))
+	}
+	return s.keyPlaceholders(fields), keys, nil
+}
+
+// quote returns the sql literal of val. Pointers and driver.Valuer values are
+// converted to the values that would be sent to the database driver, nil is
+// NULL and strings are quoted. Values whose pointer is a driver.Valuer are
+// converted by the pointer. Values of types stored in the format of the database
+// are converted by the adopter.
+func (s *SQL) quote(val interface{}) (string, error) {
+	val = s.convertValue(val)
+	if _, ok := val.(driver.Valuer); !ok && val != nil {
+		// use the Value method of the pointer, when it has one.
+		ptr := reflect.New(reflect.TypeOf(val))
+		if valuer, ok := ptr.Interface().(driver.Valuer); ok {
+			ptr.Elem().Set(reflect.ValueOf(val))
+			val = valuer
+		}
+	}
+	v, err := driver.DefaultParameterConverter.ConvertValue(val)
+	if err != nil {
+		return "", err
+	}
+	switch value := v.(type) {
+	case nil:
+		return "NULL", nil
+	case string:
+		return "'" + strings.Replace(value, "'", "''", -1) + "'", nil
+	case []byte:
+		// the hex format of bytea.
+		return fmt.Sprintf("'\\x%x'", value), nil
+	case time.Time:
+		return "'" + value.Format(time.RFC3339Nano) + "'", nil
+	}
+	return fmt.Sprint(v), nil
+}
+
+// convertValue returns the value the adopter writes for val. Pointers are
+// followed, driver.Valuer values and values whose pointer is a driver.Valuer
+// are not converted.
+func (s *SQL) convertValue(val interface{}) interface{} {
+	for val != nil {
+		typ := reflect.TypeOf(val)
+		if typ.Implements(valuerType) || reflect.PtrTo(typ).Implements(valuerType) {
+			return val
+		}
+		if v, ok := s.adopter.ConvertValue(val); ok {
+			return v
+		}
+		rv := reflect.ValueOf(val)
+		if rv.Kind() != reflect.Ptr {
+			return val
+		}
+		if rv.IsNil() {
+			return nil
+		}
+		val = rv.Elem().Interface()
 	}
-	return fmt.Sprint(val)
+	return val
 }
@@ -86,7 +86,7 @@ func TestValues(t *testing.T) {
 		cols []string
 		vals []interface{}
 	}{
-		{0, "hello", []string{"Name"}, []interface{}{"hello"}},
+		{0, "hello", []string{"name"}, []interface{}{"hello"}},
 	}
 
 	model, err := loadTable(&golangster{})
@@ -119,7 +119,7 @@ func TestSQL_WHere(t *testing.T) {
 	if err != nil {
 		t.Fatal(err)
 	}
-	exp := "WHERE Name='hello';"
+	exp := "WHERE name='hello';"
 	if strings.TrimSpace(query) != exp {
 		t.Errorf("expected %s got %s", exp, query)
 	}
@@ -168,7 +168,7 @@ func TestSQL_Select(t *testing.T) {
 	if err != nil {
 		t.Fatal(err)
 	}
-	comibeExpect := "SELECT * FROM golangster WHERE Name='gernest';"
+	comibeExpect := "SELECT * FROM golangster WHERE name='gernest';"
 	if strings.TrimSpace(query) != comibeExpect {
 		t.Errorf("expected %s got %s", comibeExpect, query)
 	}
@@ -220,11 +220,11 @@ func TestSQL_Update(t *testing.T) {
 	defer func() { _ = db.DropTable(&golangster{}) }()
 
 	_ = db.Register(&golangster{})
-	query, err := db.update(&golangster{ID: 2, Name: "gernest the golangster"})
+	query, _, err := db.update(&golangster{ID: 2, Name: "gernest the golangster"})
 	if err != nil {
 		t.Fatal(err)
 	}
-	expect := "UPDATE golangster SET Name ='gernest the golangster' WHERE  ID=2"
+	expect := "UPDATE golangster SET name ='gernest the golangster' WHERE id=$1"
 	if query != expect {
 		t.Errorf("expected %s got %s", expect, query)
 	}
@@ -291,3 +291,136 @@ func TestSQL_Find(t *testing.T) {
 	}
 
 }
+
+type rowScanner []interface{}
+
+func (r rowScanner) Scan(dest ...interface{}) error {
+	for k, v := range dest {
+		reflect.ValueOf(v).Elem().Set(reflect.ValueOf(r[k]))
+	}
+	return nil
+}
+
+func TestSQL_ScanStruct(t *testing.T) {
+	db, err := Open("postgres", testDB.ps)
+	if err != nil {
+		t.Fatal(err)
+	}
+	m := &taggedModel{}
+	row := rowScanner{int64(1), interface{}("ignored"), "gernest"}
+	err = db.scanStruct(row, []string{"id", "extra", "user_name"}, m)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if m.ID != 1 {
+		t.Errorf("expected 1 got %d", m.ID)
+	}
+	if m.UserName != "gernest" {
+		t.Errorf("expected gernest got %s", m.UserName)
+	}
+}
+
+func TestSQL_PrimaryKey(t *testing.T) {
+	db, err := Open("postgres", testDB.ps)
+	if err != nil {
+		t.Fatal(err)
+	}
+	_ = db.Register(&membership{}, &session{})
+	sample := []struct {
+		model  interface{}
+		update string
+		delete string
+		keys   []interface{}
+	}{
+		{
+			&membership{Team: 1, UserCode: "gernest", Role: "admin"},
+			"UPDATE membership SET role ='admin' WHERE team=$1 AND user_code=$2",
+			"DELETE FROM membership WHERE team=$1 AND user_code=$2",
+			[]interface{}{int64(1), "gernest"},
+		},
+		{
+			&session{Token: "1' OR '1'='1", Data: "hello"},
+			"UPDATE session SET data ='hello' WHERE token=$1",
+			"DELETE FROM session WHERE token=$1",
+			[]interface{}{"1' OR '1'='1"},
+		},
+	}
+	for _, v := range sample {
+		query, args, err := db.update(v.model)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if query != v.update {
+			t.Errorf("expected %s got %s", v.update, query)
+		}
+		if !reflect.DeepEqual(args, v.keys) {
+			t.Errorf("expected %v got %v", v.keys, args)
+		}
+		query, args, err = db.delete(v.model)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if query != v.delete {
+			t.Errorf("expected %s got %s", v.delete, query)
+		}
+		if !reflect.DeepEqual(args, v.keys) {
+			t.Errorf("expected %v got %v", v.keys, args)
+		}
+	}
+
+	// all parts of the key are required
+	_, _, err = db.update(&membership{Team: 1, Role: "admin"})
+	if err == nil {
+		t.Error("expected an error")
+	}
+	err = db.FindByKey(&membership{}, 1)
+	if err == nil {
+		t.Error("expected an error")
+	}
+
+	// key values are passed as arguments, not spliced into the query
+	tb, err := db.loader(&membership{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	key := "1' OR '1'='1"
+	query, args, err := db.Copy().Select(&membership{}).Where(db.keyPlaceholders(primaryKeys(tb)), key, "gernest").BuildQuery()
+	if err != nil {
+		t.Fatal(err)
+	}
+	expect := "SELECT * FROM membership WHERE team=$1 AND user_code=$2;"
+	if query != expect {
+		t.Errorf("expected %s got %s", expect, query)
+	}
+	if len(args) != 2 || args[0] != key || args[1] != "gernest" {
+		t.Errorf("expected the keys as arguments got %v", args)
+	}
+}
+
+type priced struct {
+	ID    int64
+	Price money
+}
+
+func TestSQL_WhereError(t *testing.T) {
+	db, err := Open("postgres", testDB.ps)
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = db.Register(&golangster{}, &priced{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	sample := []*SQL{
+		db.Copy().Select(&priced{}).Where(&priced{Price: money{Units: 1}}),
+		db.Copy().Select(&golangster{}).Where("id=1").WhereAny("id", make(chan int)),
+		db.Copy().Select(&golangster{}).WhereArrayContains("id", make(chan int)).Where(&golangster{ID: 1}),
+		db.Copy().Select(&golangster{}).WhereJSONContains("name", make(chan int)),
+	}
+	for _, v := range sample {
+		query, _, err := v.BuildQuery()
+		if err == nil {
+			t.Errorf("expected an error got %s", query)
+		}
+	}
+}
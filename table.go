@@ -15,9 +15,13 @@ var (
 	//ErrNoFlag is returned when the flag is not found
 	ErrNoFlag   = errors.New("no flag found")
 	specialTags = struct {
-		fieldName, fieldType, relation string
+		fieldName, fieldType, relation, primaryKey, auto      string
+		notNull, unique, defaultValue, check, size, precision string
+		index, uniqueIndex, onDelete, onUpdate, json          string
 	}{
-		"name", "type", "relation",
+		"name", "type", "relation", "pk", "auto",
+		"not null", "unique", "default", "check", "size", "precision",
+		"index", "unique_index", "on_delete", "on_update", "json",
 	}
 )
 
@@ -58,6 +62,11 @@ type Field interface {
 
 //Flag is an interface for tagging objects. This can hold additional information
 //about fields or tables.
+//
+// For flags loaded from struct tags, Name is the name of the struct tag( sql),
+// Key is the option and Value is the value of the option if any. For instance
+// the tag `sql:"name:user_name;not null"` gives two flags, the first with key
+// name and value user_name and the second with key not null and no value.
 type Flag interface {
 	Name() string
 	Key() string
@@ -65,9 +74,10 @@ type Flag interface {
 }
 
 type table struct {
-	name   string
-	fields []*field
-	tags   []*tag
+	name    string
+	fields  []*field
+	tags    []*tag
+	indexes []Index // indexes declared by the model
 }
 
 //LoadFunc is an interface for loading tables from models. Models are structs
@@ -104,9 +114,57 @@ func loadTable(model interface{}) (Table, error) {
 		f.loadTags(tags)
 		t.fields = append(t.fields, f)
 	}
+	t.loadKeys()
+	t.loadRelations()
+	if i, ok := model.(Indexer); ok {
+		t.indexes = i.Indexes()
+	}
 	return t, nil
 }
 
+// loadKeys flags the primary key of t as auto when it is made of a single
+// integer field without an explicit type. Adopters use auto incrementing
+// columns for such keys.
+func (t *table) loadKeys() {
+	keys := primaryKeys(t)
+	if len(keys) != 1 {
+		return
+	}
+	f := keys[0].(*field)
+	if hasFlag(f, specialTags.fieldType) || hasFlag(f, specialTags.auto) {
+		return
+	}
+	switch f.typ.Kind() {
+	case reflect.Int, reflect.Int16, reflect.Int32, reflect.Int64:
+		f.tags = append(f.tags, &tag{name: "sql", key: specialTags.auto})
+	}
+}
+
+// primaryKeys returns the fields which make up the primary key of t. These are
+// the fields tagged with pk, when there is none the field whose column name is
+// id is used.
+func primaryKeys(t Table) []Field {
+	fields, err := t.Fields()
+	if err != nil {
+		return nil
+	}
+	var keys []Field
+	for _, v := range fields {
+		if hasFlag(v, specialTags.primaryKey) {
+			keys = append(keys, v)
+		}
+	}
+	if keys != nil {
+		return keys
+	}
+	for _, v := range fields {
+		if v.ColumnName() == "id" {
+			return []Field{v}
+		}
+	}
+	return nil
+}
+
 // tabulizeName changes name to a good database name. This means
 //   CamelCame will be changed to camel_case
 //   MIXEDCase will be changed to mixed_case
@@ -262,27 +320,61 @@ func (f *field) SetValue(v interface{}) error {
 	return nil
 }
 
+//ColumnName returns the value of the name option when it is set in the tags,
+//otherwise the snake case version of the field name is returned.
 func (f *field) ColumnName() string {
-	for _, v := range f.tags {
-		if v.name == "field_name" {
-			return v.value
-		}
+	if name, ok := flagValue(f, specialTags.fieldName); ok && name != "" {
+		return name
 	}
 	return tabulizeName(f.name)
 }
 
+// loadTags loads flags from the sql struct tag. Options are separated by
+// semicolons, an option is either a key or a key:value pair.
+//	`sql:"name:user_name;type:varchar(64);pk;not null;unique;default:now()"`
+//
+// Keys are case insensitive, the value is everything after the first colon.
 func (f *field) loadTags(sqlTags string) {
 	if sqlTags == "" {
 		return
 	}
-	chunks := strings.Split(sqlTags, ",")
-	if len(chunks) > 0 {
-		for _, v := range chunks {
-			f.tags = append(f.tags, &tag{name: "sql", value: v})
+	for _, v := range strings.Split(sqlTags, ";") {
+		v = strings.TrimSpace(v)
+		if v == "" {
+			continue
+		}
+		t := &tag{name: "sql"}
+		key := v
+		if n := strings.Index(v, ":"); n > 0 {
+			key = v[:n]
+			t.value = strings.TrimSpace(v[n+1:])
 		}
+		t.key = strings.ToLower(strings.Join(strings.Fields(key), " "))
+		f.tags = append(f.tags, t)
 	}
 }
 
+// flagValue returns the value of the flag of f with the given key, ok is false
+// when f has no such flag.
+func flagValue(f Field, key string) (value string, ok bool) {
+	flags, err := f.Flags()
+	if err != nil {
+		return "", false
+	}
+	for _, v := range flags {
+		if v.Key() == key {
+			return v.Value(), true
+		}
+	}
+	return "", false
+}
+
+// hasFlag returns true if f has a flag with the given key.
+func hasFlag(f Field, key string) bool {
+	_, ok := flagValue(f, key)
+	return ok
+}
+
 type tag struct {
 	name, key, value string
 }
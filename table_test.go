@@ -42,3 +42,59 @@ func TestLoadTable(t *testing.T) {
 		t.Errorf("expected %s got %s", name, tb.Name())
 	}
 }
+
+type taggedModel struct {
+	ID       int64  `sql:"pk"`
+	UserName string `sql:"name:user_name;type:varchar(64); NOT  NULL;default:'x:y'"`
+	Ignored  string `sql:"-"`
+}
+
+func TestField_LoadTags(t *testing.T) {
+	tb, err := loadTable(&taggedModel{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if tb.Size() != 2 {
+		t.Fatalf("expected 2 fields got %d", tb.Size())
+	}
+	fields, err := tb.Fields()
+	if err != nil {
+		t.Fatal(err)
+	}
+	f := fields[1]
+	if f.ColumnName() != "user_name" {
+		t.Errorf("expected user_name got %s", f.ColumnName())
+	}
+	sample := []struct {
+		key, value string
+	}{
+		{"name", "user_name"},
+		{"type", "varchar(64)"},
+		{"not null", ""},
+		{"default", "'x:y'"},
+	}
+	flags, err := f.Flags()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(flags) != len(sample) {
+		t.Fatalf("expected %d flags got %d", len(sample), len(flags))
+	}
+	for k, v := range sample {
+		if flags[k].Name() != "sql" {
+			t.Errorf("expected sql got %s", flags[k].Name())
+		}
+		if flags[k].Key() != v.key {
+			t.Errorf("expected %s got %s", v.key, flags[k].Key())
+		}
+		if flags[k].Value() != v.value {
+			t.Errorf("expected %s got %s", v.value, flags[k].Value())
+		}
+	}
+	if !hasFlag(fields[0], "pk") {
+		t.Error("expected id to have pk flag")
+	}
+	if fields[0].ColumnName() != "id" {
+		t.Errorf("expected id got %s", fields[0].ColumnName())
+	}
+}
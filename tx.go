@@ -0,0 +1,93 @@
+package orange
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+)
+
+// ErrNoTx is returned when committing or rolling back a *SQL which is not
+// bound to a transaction.
+var ErrNoTx = errors.New("not in a transaction")
+
+// executor is implemented by *sql.DB, *sql.Conn and *sql.Tx.
+type executor interface {
+	ExecContext(ctx context.Context, query string, args ...interface{}) (sql.Result, error)
+	QueryContext(ctx context.Context, query string, args ...interface{}) (*sql.Rows, error)
+	QueryRowContext(ctx context.Context, query string, args ...interface{}) *sql.Row
+}
+
+// conn returns the transaction when s is bound to one, then the connection s is
+// bound to, otherwise the database is returned.
+func (s *SQL) conn() executor {
+	if s.tx != nil {
+		return s.tx
+	}
+	if s.session != nil {
+		return s.session
+	}
+	return s.db
+}
+
+// Begin starts a transaction and returns a copy of s bound to it. All
+// statements executed by the returned *SQL, including the ones executed by
+// model hooks, run inside the transaction until Commit or Rollback is called.
+func (s *SQL) Begin() (*SQL, error) {
+	if s.tx != nil {
+		return nil, errors.New("already in a transaction")
+	}
+	var tx *sql.Tx
+	var err error
+	if s.session != nil {
+		tx, err = s.session.BeginTx(s.context(), nil)
+	} else {
+		tx, err = s.db.BeginTx(s.context(), nil)
+	}
+	if err != nil {
+		return nil, err
+	}
+	dup := s.Copy()
+	dup.tx = tx
+	return dup, nil
+}
+
+// Tx returns the transaction that s is bound to, nil is returned if s is not
+// in a transaction.
+func (s *SQL) Tx() *sql.Tx {
+	return s.tx
+}
+
+// Commit commits the transaction.
+func (s *SQL) Commit() error {
+	if s.tx == nil {
+		return ErrNoTx
+	}
+	return s.tx.Commit()
+}
+
+// Rollback aborts the transaction.
+func (s *SQL) Rollback() error {
+	if s.tx == nil {
+		return ErrNoTx
+	}
+	return s.tx.Rollback()
+}
+
+// inTx calls fn with a copy of s bound to a transaction. The transaction is
+// committed when fn succeeds and rolled back otherwise. When s is already in a
+// transaction or it is a dry run fn is called with s.
+func (s *SQL) inTx(fn func(*SQL) error) error {
+	if s.tx != nil || s.dryRun != nil {
+		return fn(s)
+	}
+	tx, err := s.Begin()
+	if err != nil {
+		return err
+	}
+	err = fn(tx)
+	if err != nil {
+		_ = tx.Rollback()
+		return err
+	}
+	return tx.Commit()
+}
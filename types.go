@@ -0,0 +1,173 @@
+package orange
+
+import (
+	"database/sql"
+	"database/sql/driver"
+	"fmt"
+	"reflect"
+	"sync"
+)
+
+// basicTypes are the types used for looking up named types by their kind e.g a
+// field of type status string uses the column type of string.
+var basicTypes = map[reflect.Kind]reflect.Type{
+	reflect.String:  reflect.TypeOf(""),
+	reflect.Bool:    reflect.TypeOf(false),
+	reflect.Int:     reflect.TypeOf(int(0)),
+	reflect.Int8:    reflect.TypeOf(int8(0)),
+	reflect.Int16:   reflect.TypeOf(int16(0)),
+	reflect.Int32:   reflect.TypeOf(int32(0)),
+	reflect.Int64:   reflect.TypeOf(int64(0)),
+	reflect.Uint:    reflect.TypeOf(uint(0)),
+	reflect.Uint8:   reflect.TypeOf(uint8(0)),
+	reflect.Uint16:  reflect.TypeOf(uint16(0)),
+	reflect.Uint32:  reflect.TypeOf(uint32(0)),
+	reflect.Uint64:  reflect.TypeOf(uint64(0)),
+	reflect.Float32: reflect.TypeOf(float32(0)),
+	reflect.Float64: reflect.TypeOf(float64(0)),
+}
+
+var (
+	bytesType   = reflect.TypeOf([]byte(nil))
+	valuerType  = reflect.TypeOf((*driver.Valuer)(nil)).Elem()
+	scannerType = reflect.TypeOf((*sql.Scanner)(nil)).Elem()
+)
+
+// nullableType returns true if the zero value of typ is NULL. These are pointers
+// and driver.Valuer types like sql.NullString whose zero value has no value.
+func nullableType(typ reflect.Type) bool {
+	if typ.Kind() == reflect.Ptr {
+		return true
+	}
+	if !valuerKind(typ) {
+		return false
+	}
+	v, err := zeroValue(typ)
+	return err == nil && v == nil
+}
+
+// valuerKind returns true if typ or a pointer to typ implements driver.Valuer.
+func valuerKind(typ reflect.Type) bool {
+	return typ.Implements(valuerType) || reflect.PtrTo(typ).Implements(valuerType)
+}
+
+// zeroValue returns the value of the zero value of the driver.Valuer typ.
+func zeroValue(typ reflect.Type) (v driver.Value, err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("value of zero %s: %v", typ, r)
+		}
+	}()
+	return reflect.New(typ).Interface().(driver.Valuer).Value()
+}
+
+// decimalKind returns true if values of kind can hold fractional numbers in a
+// numeric column. These are floats, strings and types like big.Rat, integers
+// and booleans can not be scanned from a numeric with a scale.
+func decimalKind(kind reflect.Kind) bool {
+	switch kind {
+	case reflect.Bool,
+		reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return false
+	}
+	return true
+}
+
+// indirect returns the type that typ points to, or typ if it is not a pointer.
+func indirect(typ reflect.Type) reflect.Type {
+	for typ.Kind() == reflect.Ptr {
+		typ = typ.Elem()
+	}
+	return typ
+}
+
+// SQLTyper is implemented by field types which declare their column type, for
+// instance types implementing driver.Valuer and sql.Scanner.
+//
+//	func (Money) SQLType(adopter string) string {
+//		return "numeric(20,4)"
+//	}
+//
+// adopter is the name of the adopter e.g postgres. Returning an empty string
+// leaves the column type to the adopter.
+type SQLTyper interface {
+	SQLType(adopter string) string
+}
+
+// sqlType returns the column type declared by typ for adopter, typ or a pointer
+// to typ has to implement SQLTyper.
+func sqlType(typ reflect.Type, adopter string) string {
+	typ = indirect(typ)
+	if t, ok := reflect.Zero(typ).Interface().(SQLTyper); ok {
+		return t.SQLType(adopter)
+	}
+	if t, ok := reflect.New(typ).Interface().(SQLTyper); ok {
+		return t.SQLType(adopter)
+	}
+	return ""
+}
+
+// typeRegistry maps Go types to column types.
+type typeRegistry struct {
+	mu    sync.RWMutex
+	types map[reflect.Type]string
+}
+
+func newTypeRegistry(defaults map[reflect.Type]string) *typeRegistry {
+	r := &typeRegistry{types: make(map[reflect.Type]string)}
+	for k, v := range defaults {
+		r.types[k] = v
+	}
+	return r
+}
+
+// register maps typ to column, replacing the previous column type of typ.
+func (r *typeRegistry) register(typ reflect.Type, column string) {
+	r.mu.Lock()
+	r.types[typ] = column
+	r.mu.Unlock()
+}
+
+// lookup returns the column type of typ. Types which are not registered use the
+// column type of their kind, byte slices use the column type of []byte and
+// pointers use the column type of the type they point to.
+func (r *typeRegistry) lookup(typ reflect.Type) (string, bool) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	for typ.Kind() == reflect.Ptr {
+		if v, ok := r.types[typ]; ok {
+			return v, true
+		}
+		typ = typ.Elem()
+	}
+	if v, ok := r.types[typ]; ok {
+		return v, true
+	}
+	if typ.Kind() == reflect.Slice && typ.Elem().Kind() == reflect.Uint8 {
+		v, ok := r.types[bytesType]
+		return v, ok
+	}
+	if b, ok := basicTypes[typ.Kind()]; ok {
+		v, ok := r.types[b]
+		return v, ok
+	}
+	return "", false
+}
+
+// RegisterType sets the column type for fields of the type of value, for the
+// adopter of s. It can be used for custom types and for changing the column
+// types of the builtin types.
+//
+//	db.RegisterType(Money{}, "numeric(12,2)")
+//	db.RegisterType(float64(0), "numeric")
+//
+// Fields tagged with a type are not affected.
+func (s *SQL) RegisterType(value interface{}, column string) *SQL {
+	typ, ok := value.(reflect.Type)
+	if !ok {
+		typ = reflect.TypeOf(value)
+	}
+	s.adopter.RegisterType(typ, column)
+	return s
+}
@@ -0,0 +1,100 @@
+package orange
+
+import (
+	"encoding/json"
+	"reflect"
+	"testing"
+	"time"
+)
+
+type money struct {
+	Units int64
+	Nanos int32
+}
+
+type status string
+
+type typedModel struct {
+	ID      int16
+	Small   int8
+	Count   uint32
+	Big     uint64
+	Ratio   float32
+	Amount  float64
+	Data    []byte
+	Raw     json.RawMessage
+	State   status
+	Code    string `sql:"size:8"`
+	Price   money
+	Total   money `sql:"type:numeric(10,2)"`
+	Created time.Time
+}
+
+func TestPostgres_ColumnType(t *testing.T) {
+	db, err := Open("postgres", testDB.ps)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tab, err := loadTable(&typedModel{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	fields, err := tab.Fields()
+	if err != nil {
+		t.Fatal(err)
+	}
+	column := func(name string) (string, error) {
+		for _, f := range fields {
+			if f.Name() == name {
+				return db.adopter.ColumnType(f)
+			}
+		}
+		t.Fatalf("no field %s", name)
+		return "", nil
+	}
+	_, err = column("Price")
+	if err == nil {
+		t.Error("expected an error for an unregistered type")
+	}
+	db.RegisterType(money{}, "numeric(20,9)")
+	db.RegisterType(reflect.TypeOf(float32(0)), "float4")
+	sample := []struct {
+		field, expect string
+	}{
+		{"ID", "smallserial"},
+		{"Small", "smallint"},
+		{"Count", "bigint"},
+		{"Big", "numeric(20,0)"},
+		{"Ratio", "float4"},
+		{"Amount", "double precision"},
+		{"Data", "bytea"},
+		{"Raw", "bytea"},
+		{"State", "text"},
+		{"Code", "varchar(8)"},
+		{"Price", "numeric(20,9)"},
+		{"Total", "numeric(10,2)"},
+		{"Created", "timestamp with time zone"},
+	}
+	for _, v := range sample {
+		typ, err := column(v.field)
+		if err != nil {
+			t.Errorf("%s: %v", v.field, err)
+			continue
+		}
+		if typ != v.expect {
+			t.Errorf("%s: expected %s got %s", v.field, v.expect, typ)
+		}
+	}
+	other, err := Open("postgres", testDB.ps)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, f := range fields {
+		if f.Name() == "Ratio" {
+			typ, _ := other.adopter.ColumnType(f)
+			if typ != "real" {
+				t.Errorf("expected real got %s", typ)
+			}
+		}
+	}
+}
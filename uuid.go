@@ -0,0 +1,62 @@
+package orange
+
+import (
+	"database/sql/driver"
+	"encoding/hex"
+	"fmt"
+	"strings"
+)
+
+// UUID is a universally unique identifier, it is stored in uuid columns. The
+// zero UUID is stored as NULL.
+type UUID [16]byte
+
+// ParseUUID parses the text form of a UUID, with or without hyphens.
+func ParseUUID(s string) (UUID, error) {
+	var u UUID
+	text := strings.Replace(strings.Trim(s, "{}"), "-", "", -1)
+	if len(text) != 32 {
+		return u, fmt.Errorf("invalid UUID %s", s)
+	}
+	_, err := hex.Decode(u[:], []byte(text))
+	if err != nil {
+		return u, fmt.Errorf("invalid UUID %s: %v", s, err)
+	}
+	return u, nil
+}
+
+// String returns the text form of u e.g 6ba7b810-9dad-11d1-80b4-00c04fd430c8.
+func (u UUID) String() string {
+	b := hex.EncodeToString(u[:])
+	return b[:8] + "-" + b[8:12] + "-" + b[12:16] + "-" + b[16:20] + "-" + b[20:]
+}
+
+// Value implements driver.Valuer.
+func (u UUID) Value() (driver.Value, error) {
+	if u == (UUID{}) {
+		return nil, nil
+	}
+	return u.String(), nil
+}
+
+// Scan implements sql.Scanner.
+func (u *UUID) Scan(src interface{}) error {
+	switch v := src.(type) {
+	case nil:
+		*u = UUID{}
+		return nil
+	case string:
+		id, err := ParseUUID(v)
+		*u = id
+		return err
+	case []byte:
+		if len(v) == 16 {
+			copy(u[:], v)
+			return nil
+		}
+		id, err := ParseUUID(string(v))
+		*u = id
+		return err
+	}
+	return fmt.Errorf("can not scan %T as UUID", src)
+}
@@ -0,0 +1,164 @@
+package orange
+
+import (
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"fmt"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// cents is an amount of money stored as a decimal.
+type cents int64
+
+func (c cents) Value() (driver.Value, error) {
+	return fmt.Sprintf("%d.%02d", c/100, c%100), nil
+}
+
+func (c *cents) Scan(src interface{}) error {
+	var units, fraction int64
+	_, err := fmt.Sscanf(fmt.Sprintf("%s", src), "%d.%d", &units, &fraction)
+	*c = cents(units*100 + fraction)
+	return err
+}
+
+func (cents) SQLType(adopter string) string {
+	return "numeric(12,2)"
+}
+
+// secret is stored reversed, its methods have pointer receivers.
+type secret string
+
+func (s *secret) Value() (driver.Value, error) {
+	return reverse(string(*s)), nil
+}
+
+func (s *secret) Scan(src interface{}) error {
+	v, ok := src.(string)
+	if !ok {
+		return errors.New("secret is not a string")
+	}
+	*s = secret(reverse(v))
+	return nil
+}
+
+func (s *secret) SQLType(adopter string) string {
+	if adopter == "postgres" {
+		return "bytea"
+	}
+	return ""
+}
+
+func reverse(s string) string {
+	r := []rune(s)
+	for i, j := 0, len(r)-1; i < j; i, j = i+1, j-1 {
+		r[i], r[j] = r[j], r[i]
+	}
+	return string(r)
+}
+
+// driverScanner scans the values like database/sql, destinations which are
+// sql.Scanner scan the raw values.
+type driverScanner []interface{}
+
+func (r driverScanner) Scan(dest ...interface{}) error {
+	for k, v := range dest {
+		if s, ok := v.(sql.Scanner); ok {
+			if err := s.Scan(r[k]); err != nil {
+				return err
+			}
+			continue
+		}
+		reflect.ValueOf(v).Elem().Set(reflect.ValueOf(r[k]))
+	}
+	return nil
+}
+
+type wallet struct {
+	ID      int64
+	Balance cents
+	Pin     secret
+}
+
+func TestSQL_Valuer(t *testing.T) {
+	db, err := Open("postgres", testDB.ps)
+	if err != nil {
+		t.Fatal(err)
+	}
+	query, err := db.adopter.Create(mustLoad(t, &wallet{}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	create := "CREATE TABLE IF NOT EXISTS wallet (id bigserial,balance numeric(12,2),pin bytea,PRIMARY KEY (id));"
+	if query != create {
+		t.Errorf("expected %s got %s", create, query)
+	}
+	dry := db.DryRun()
+	err = dry.Create(&wallet{ID: 1, Balance: 1205, Pin: "1234"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	insert := "INSERT INTO wallet (id, balance, pin) VALUES (1, '12.05', '4321');"
+	if got := dry.Statements()[0].Query; got != insert {
+		t.Errorf("expected %s got %s", insert, got)
+	}
+
+	// zero values are written by their Value method, not as NULL
+	dry = db.DryRun()
+	err = dry.Create(&wallet{ID: 1, Pin: "1"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = dry.UpdateColumns(&wallet{ID: 1, Pin: "9"}, "balance")
+	if err != nil {
+		t.Fatal(err)
+	}
+	expect := []string{
+		"INSERT INTO wallet (id, balance, pin) VALUES (1, '0.00', '1');",
+		"UPDATE wallet SET balance ='0.00',pin ='9' WHERE id=$1",
+	}
+	for k, v := range dry.Statements() {
+		if v.Query != expect[k] {
+			t.Errorf("expected %s got %s", expect[k], v.Query)
+		}
+	}
+	cond, _, err := db.Where(&wallet{Pin: "1234"}).BuildQuery()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(cond, "pin='4321'") {
+		t.Errorf("expected pin='4321' in %s", cond)
+	}
+
+	w := &wallet{}
+	row := driverScanner{int64(1), "12.05", "4321"}
+	err = db.scanStruct(row, []string{"id", "balance", "pin"}, w)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if w.Balance != 1205 || w.Pin != "1234" {
+		t.Errorf("expected 1205 1234 got %d %s", w.Balance, w.Pin)
+	}
+}
+
+func TestNullableType(t *testing.T) {
+	sample := []struct {
+		value  interface{}
+		expect bool
+	}{
+		{new(int), true},
+		{sql.NullString{}, true},
+		{UUID{}, true},
+		{cents(0), false},
+		{secret(""), false},
+		{int64(0), false},
+		{"", false},
+	}
+	for _, v := range sample {
+		if n := nullableType(reflect.TypeOf(v.value)); n != v.expect {
+			t.Errorf("%T: expected %v got %v", v.value, v.expect, n)
+		}
+	}
+}